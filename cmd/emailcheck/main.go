@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/ipxsandbox/config"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/repository/user"
+)
+
+const usage = `Usage: emailcheck [flags]
+
+Reports users whose emails differ only by case or surrounding whitespace,
+which block the case-insensitive unique index migration, and emails that are
+not in normalized form. With -fix the latter are rewritten; collisions must be
+resolved by hand. Exits with status 1 while collisions or invalid emails remain.
+
+Flags:
+`
+
+var errUnresolved = errors.New("unresolved email problems found")
+
+func main() {
+	fix := flag.Bool("fix", false, "rewrite emails that are not normalized and do not collide")
+	localPart := flag.String("local-part", config.Default().Auth.EmailLocalPart, "local part policy, must match EMAIL_LOCAL_PART_POLICY")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *localPart, *fix); err != nil {
+		fmt.Fprintln(os.Stderr, "emailcheck:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, localPart string, fix bool) error {
+	normalizer, err := emailutil.New(localPart)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	db, err := config.InitDB(ctx, *cfg)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	report, err := user.ScanEmails(ctx, db, normalizer)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(report.Collisions) > 0 {
+		fmt.Fprintf(w, "%d email collisions:\n", len(report.Collisions))
+		for _, c := range report.Collisions {
+			for _, u := range c.Users {
+				fmt.Fprintf(w, "  %s\tid=%d\t%q\n", c.Key, u.ID, u.Email)
+			}
+		}
+	}
+	if len(report.Invalid) > 0 {
+		fmt.Fprintf(w, "%d invalid emails:\n", len(report.Invalid))
+		for _, u := range report.Invalid {
+			fmt.Fprintf(w, "  id=%d\t%q\n", u.ID, u.Email)
+		}
+	}
+	if len(report.Changes) > 0 {
+		fmt.Fprintf(w, "%d emails not normalized:\n", len(report.Changes))
+		for _, c := range report.Changes {
+			fmt.Fprintf(w, "  id=%d\t%q\t-> %q\n", c.ID, c.From, c.To)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if fix && len(report.Changes) > 0 {
+		if err := user.ApplyEmailChanges(ctx, db, report.Changes); err != nil {
+			return fmt.Errorf("normalize emails: %w", err)
+		}
+		fmt.Printf("normalized %d emails\n", len(report.Changes))
+	}
+
+	if len(report.Collisions) > 0 || len(report.Invalid) > 0 {
+		return errUnresolved
+	}
+	if len(report.Changes) == 0 {
+		fmt.Println("no email problems found")
+	}
+	return nil
+}
@@ -1,26 +1,202 @@
 package main
 
 import (
-	"github.com/gin-contrib/cors"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/gin-gonic/gin"
 	"github.com/ipxsandbox/config"
-	"github.com/ipxsandbox/internal/routes"
+	"github.com/ipxsandbox/internal/audit"
+	"github.com/ipxsandbox/internal/handler"
+	"github.com/ipxsandbox/internal/middleware"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
+	"github.com/ipxsandbox/internal/pkg/health"
+	"github.com/ipxsandbox/internal/pkg/jwtutil"
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"github.com/ipxsandbox/internal/pkg/metrics"
+	"github.com/ipxsandbox/internal/pkg/migrate"
 	"github.com/ipxsandbox/internal/pkg/redis"
+	"github.com/ipxsandbox/internal/pkg/tracing"
+	passwordHistoryRepository "github.com/ipxsandbox/internal/repository/passwordhistory"
+	"github.com/ipxsandbox/internal/routes"
+	"github.com/ipxsandbox/internal/server"
+	passwordHistoryUsecase "github.com/ipxsandbox/internal/usecase/passwordhistory"
+	"github.com/ipxsandbox/migrations"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	db := config.InitDB()
-	redis.InitRedis()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	log, err := logger.New(cfg.Log.LoggerConfig())
+	if err != nil {
+		fatal("Failed to create logger", err)
+	}
+	slog.SetDefault(log)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing.TracerConfig())
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+
+	db, err := config.InitDB(ctx, cfg.Database)
+	if err != nil {
+		fatal("Failed to connect to database", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("Failed to access database pool", err)
+	}
+	if cfg.Database.AutoMigrate {
+		if err := runMigrations(ctx, sqlDB, cfg.Database.Dialect()); err != nil {
+			fatal("Failed to run database migrations", err)
+		}
+	}
+	if err := redis.InitRedis(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB); err != nil {
+		fatal("Failed to initialize redis", err)
+	}
+
+	hasher, err := hashutil.New(cfg.Password.HasherConfig())
+	if err != nil {
+		fatal("Failed to create password hasher", err)
+	}
+	emailNormalizer, err := emailutil.New(cfg.Auth.EmailLocalPart)
+	if err != nil {
+		fatal("Failed to create email normalizer", err)
+	}
+	jwtManager := jwtutil.New(cfg.JWT.Secret, cfg.JWT.AccessTTL, cfg.JWT.RefreshTTL)
+	cookies, err := cookieutil.New(cfg.CookiePolicyConfig())
+	if err != nil {
+		fatal("Failed to create cookie policy", err)
+	}
+
+	var auditSinks []audit.Sink
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		if err := m.Register(metrics.NewDBStatsCollector(sqlDB, "primary")); err != nil {
+			fatal("Failed to register database metrics", err)
+		}
+		if err := m.Register(metrics.NewRedisPoolCollector(redis.Rdb)); err != nil {
+			fatal("Failed to register redis metrics", err)
+		}
+		auditSinks = append(auditSinks, m.AuditSink())
+	}
+
+	// metrics ต้องอยู่ก่อน ErrorHandler เพื่อให้เห็น status ที่ ErrorHandler เขียนจาก c.Errors
+	handlers := []gin.HandlerFunc{
+		otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithGinFilter(skipProbes(cfg))),
+		middleware.RequestID(),
+		middleware.SecurityHeaders(cfg.Security.MiddlewareConfig()),
+		middleware.RequestLogger(log),
+	}
+	if m != nil {
+		handlers = append(handlers, m.Middleware())
+	}
+	handlers = append(handlers,
+		middleware.Recovery(),
+		middleware.ErrorHandler(),
+		middleware.CORS(cfg.CORS.MiddlewareConfig()),
+		middleware.BodyLimit(cfg.Server.MaxBodyBytes, cfg.Server.BodyLimits),
+		middleware.Timeout(cfg.Server.RequestTimeout),
+		middleware.ReadYourWrites(),
+	)
+
+	r := gin.New()
+	r.Use(handlers...)
+	if m != nil {
+		r.GET(cfg.Metrics.Path, gin.WrapH(m.Handler()))
+	}
+
+	srv := server.New(cfg.Server.HTTPConfig(), r)
+
+	var auditFile *audit.JSONLinesSink
+	if cfg.Audit.LogFile != "" {
+		auditFile, err = audit.NewJSONLinesSink(cfg.Audit.LogFile)
+		if err != nil {
+			fatal("Failed to open audit log file", err)
+		}
+		auditSinks = append(auditSinks, auditFile)
+	}
+
+	healthRegistry := health.NewRegistry()
+	healthRegistry.Register(cfg.Database.Driver, cfg.Health.DatabaseTimeout, sqlDB.PingContext)
+	healthRegistry.Register("redis", cfg.Health.RedisTimeout, func(ctx context.Context) error {
+		return redis.Rdb.Ping(ctx).Err()
+	})
+	srv.BeforeShutdown(healthRegistry.SetShuttingDown)
+
+	if err := routes.InitRoutes(r, routes.Dependencies{
+		DB:             db,
+		Hasher:         hasher,
+		JWT:            jwtManager,
+		Cookies:        cookies,
+		Email:          emailNormalizer,
+		Password:       cfg.Password.PolicyConfig(),
+		PasswordMaxAge: cfg.Password.MaxAge,
+		AuthOpts:       handler.AuthOptions{ConcealRegistration: cfg.Auth.ConcealRegistration},
+		AuditSinks:     auditSinks,
+		Health:         healthRegistry,
+	}); err != nil {
+		fatal("Failed to initialize routes", err)
+	}
+
+	if interval := cfg.Password.HistoryCleanupInterval; interval > 0 {
+		cleanup := passwordHistoryUsecase.NewCleanup(passwordHistoryRepository.New(db), cfg.Password.Policy.HistoryDepth, interval, log)
+		go cleanup.Run(ctx)
+	}
+
+	srv.OnShutdown("database", func(context.Context) error { return sqlDB.Close() })
+	srv.OnShutdown("redis", func(context.Context) error { return redis.Rdb.Close() })
+	if auditFile != nil {
+		srv.OnShutdown("audit log file", func(context.Context) error { return auditFile.Close() })
+	}
+	srv.OnShutdown("tracing", shutdownTracing)
+
+	if err := srv.Run(ctx); err != nil {
+		fatal("Server stopped with error", err)
+	}
+	slog.Info("Server stopped")
+}
 
-	r := gin.Default()
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		AllowCredentials: true,
-	}))
+func runMigrations(ctx context.Context, sqlDB *sql.DB, dialect string) error {
+	m, err := migrate.New(sqlDB, dialect, migrations.FS)
+	if err != nil {
+		return err
+	}
+	applied, err := m.Up(ctx)
+	for _, mig := range applied {
+		slog.Info("Applied database migration", slog.String("migration", mig.String()))
+	}
+	return err
+}
 
-	routes.InitRoutes(r, db)
+// skipProbes ไม่สร้าง span ให้ health check และ metrics scrape ที่ถูกเรียกถี่ๆ
+func skipProbes(cfg *config.Config) otelgin.GinFilter {
+	return func(c *gin.Context) bool {
+		switch c.FullPath() {
+		case "/healthz", "/readyz", cfg.Metrics.Path:
+			return false
+		}
+		return true
+	}
+}
 
-	r.Run(":8080")
-}
\ No newline at end of file
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ipxsandbox/config"
+	"github.com/ipxsandbox/internal/pkg/migrate"
+	"github.com/ipxsandbox/migrations"
+)
+
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up              apply all pending migrations
+  down [N|all]    roll back the last N migrations (default 1)
+  status          list migrations and whether they are applied
+  create NAME     create empty up/down files for every dialect
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", "migrations", "directory that create writes new migration files into")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *dir, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, dir string, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		return errors.New("missing command")
+	}
+
+	// create ไม่ต้องเชื่อมต่อฐานข้อมูล
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New("usage: migrate create NAME")
+		}
+		files, err := migrate.Create(dir, args[1], time.Now())
+		for _, f := range files {
+			fmt.Println("created", f)
+		}
+		return err
+	}
+
+	m, closeDB, err := openMigrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Println("applied", mig)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		steps, err := parseSteps(args[1:])
+		if err != nil {
+			return err
+		}
+		rolled, err := m.Down(ctx, steps)
+		for _, mig := range rolled {
+			fmt.Println("rolled back", mig)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func parseSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	if args[0] == "all" {
+		return -1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of steps %q", args[0])
+	}
+	return n, nil
+}
+
+func openMigrator(ctx context.Context) (*migrate.Migrator, func(), error) {
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	db, err := config.InitDB(ctx, *cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := migrate.New(sqlDB, cfg.Dialect(), migrations.FS)
+	if err != nil {
+		sqlDB.Close()
+		return nil, nil, err
+	}
+	return m, func() { sqlDB.Close() }, nil
+}
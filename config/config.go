@@ -0,0 +1,780 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/middleware"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	"github.com/ipxsandbox/internal/pkg/tracing"
+	"github.com/ipxsandbox/internal/server"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+
+	minJWTSecretLength   = 32
+	minJWTSecretDistinct = 10
+
+	minProductionPasswordLength = 8
+)
+
+type Config struct {
+	App      AppConfig      `yaml:"app"`
+	Server   ServerConfig   `yaml:"server"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Security SecurityConfig `yaml:"security"`
+	Database DatabaseConfig `yaml:"database"`
+	Redis    RedisConfig    `yaml:"redis"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Cookie   CookieConfig   `yaml:"cookie"`
+	Password PasswordConfig `yaml:"password"`
+	Log      LogConfig      `yaml:"log"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Audit    AuditConfig    `yaml:"audit"`
+	Health   HealthConfig   `yaml:"health"`
+	Metrics  MetricsConfig  `yaml:"metrics"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+}
+
+type AppConfig struct {
+	Env string `yaml:"env"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"`
+	// RequestTimeout คือ deadline ของ context ต่อ request ที่ส่งต่อไปถึงฐานข้อมูลและ Redis
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	TLSCertFile    string        `yaml:"tls_cert_file"`
+	TLSKeyFile     string        `yaml:"tls_key_file"`
+	// MaxBodyBytes คือขนาด body สูงสุดของทุก route ที่ไม่ได้กำหนดไว้ใน BodyLimits (0 = ไม่จำกัด)
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// BodyLimits กำหนดขนาด body ต่อ route ในรูป "METHOD /path"
+	BodyLimits map[string]int64 `yaml:"body_limits"`
+}
+
+type CORSConfig struct {
+	// AllowOrigins รองรับ pattern ของ subdomain เช่น https://*.example.com
+	AllowOrigins     []string      `yaml:"allow_origins"`
+	AllowMethods     []string      `yaml:"allow_methods"`
+	AllowHeaders     []string      `yaml:"allow_headers"`
+	ExposeHeaders    []string      `yaml:"expose_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// SecurityConfig กำหนด security header ของทุก response
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains"`
+	HSTSPreload           bool          `yaml:"hsts_preload"`
+	FrameAncestors        string        `yaml:"frame_ancestors"`
+	ReferrerPolicy        string        `yaml:"referrer_policy"`
+}
+
+type DatabaseConfig struct {
+	// Driver เป็น postgres, mysql หรือ sqlite ถ้าไม่ตั้งจะเดาจาก URL
+	Driver string `yaml:"driver"`
+	// URL เป็น connection string แบบเต็ม ถ้าตั้งไว้จะใช้แทน Host, Port, User, Password, Name
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// AutoMigrate รัน migration ที่ค้างอยู่ตอน start ทุก replica รันพร้อมกันได้เพราะมี lock
+	AutoMigrate bool `yaml:"auto_migrate"`
+	// ReplicaURLs เป็น connection string ของ read replica (driver เดียวกับ primary)
+	// ถ้าว่างจะอ่านและเขียนที่ primary ทั้งหมด
+	ReplicaURLs []string           `yaml:"replica_urls"`
+	Pool        PoolConfig         `yaml:"pool"`
+	Connect     ConnectRetryConfig `yaml:"connect"`
+}
+
+// PoolConfig ใช้กับทั้ง primary และ replica แต่ละตัว ค่า 0 คือใช้ค่า default ของ database/sql
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// ConnectRetryConfig กำหนดการลองเชื่อมต่อใหม่ตอน start เช่นตอนที่ฐานข้อมูลยังขึ้นไม่เสร็จ
+type ConnectRetryConfig struct {
+	Attempts       int           `yaml:"attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+type JWTConfig struct {
+	Secret     string        `yaml:"secret"`
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+// CookieConfig กำหนด attribute ของ cookie ที่เก็บ token อายุของ cookie ใช้ค่าเดียวกับ JWT TTL
+type CookieConfig struct {
+	Domain      string `yaml:"domain"`
+	Secure      bool   `yaml:"secure"`
+	SameSite    string `yaml:"same_site"`
+	Path        string `yaml:"path"`
+	RefreshPath string `yaml:"refresh_path"`
+	HostPrefix  bool   `yaml:"host_prefix"`
+}
+
+type PasswordConfig struct {
+	Algorithm  string               `yaml:"algorithm"`
+	BcryptCost int                  `yaml:"bcrypt_cost"`
+	Argon2     Argon2Config         `yaml:"argon2"`
+	Policy     PasswordPolicyConfig `yaml:"policy"`
+	// HistoryCleanupInterval คือระยะห่างของการลบรหัสผ่านเดิมที่เกิน HistoryDepth (0 = ไม่รัน)
+	HistoryCleanupInterval time.Duration `yaml:"history_cleanup_interval"`
+	// MaxAge คืออายุสูงสุดของรหัสผ่านแยกตาม role เช่น admin: 2160h
+	// user ที่รหัสผ่านเก่ากว่านี้ต้องเปลี่ยนรหัสผ่านหลัง login ก่อนใช้งานอื่น role ที่ไม่ได้ระบุไม่มีวันหมดอายุ
+	MaxAge map[string]time.Duration `yaml:"max_age"`
+}
+
+// PasswordPolicyConfig ใช้ตอนสมัครและเปลี่ยนรหัสผ่านเท่านั้น ไม่ใช้ตอน login
+type PasswordPolicyConfig struct {
+	MinLength      int  `yaml:"min_length"`
+	MaxLength      int  `yaml:"max_length"`
+	RequireUpper   bool `yaml:"require_upper"`
+	RequireLower   bool `yaml:"require_lower"`
+	RequireDigit   bool `yaml:"require_digit"`
+	RequireSpecial bool `yaml:"require_special"`
+	// MaxRepeated คือจำนวนตัวอักษรเดียวกันที่ติดกันได้มากที่สุด (0 = ไม่จำกัด)
+	MaxRepeated int `yaml:"max_repeated"`
+	// HistoryDepth คือจำนวนรหัสผ่านล่าสุดที่ห้ามนำกลับมาใช้ (0 = ไม่ตรวจ)
+	HistoryDepth int `yaml:"history_depth"`
+}
+
+type Argon2Config struct {
+	MemoryKiB   uint32 `yaml:"memory_kib"`
+	Iterations  uint32 `yaml:"iterations"`
+	Parallelism uint8  `yaml:"parallelism"`
+	SaltLength  uint32 `yaml:"salt_length"`
+	KeyLength   uint32 `yaml:"key_length"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type AuthConfig struct {
+	ConcealRegistration bool `yaml:"conceal_registration"`
+	// EmailLocalPart เป็น lowercase หรือ preserve ว่าจะเก็บส่วนหน้า @ ของ email ตามที่ผู้ใช้พิมพ์หรือไม่
+	EmailLocalPart string `yaml:"email_local_part"`
+}
+
+type AuditConfig struct {
+	LogFile string `yaml:"log_file"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	ServiceName string  `yaml:"service_name"`
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	DatabaseTimeout time.Duration `yaml:"database_timeout"`
+	RedisTimeout    time.Duration `yaml:"redis_timeout"`
+}
+
+func Default() Config {
+	return Config{
+		App: AppConfig{Env: EnvDevelopment},
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			RequestTimeout:    10 * time.Second,
+			MaxBodyBytes:      1 << 20,
+			// body ของ auth มีแค่ไม่กี่ field ไม่ควรให้ส่งมาใหญ่เพื่อให้ server ต้อง hash
+			BodyLimits: map[string]int64{
+				"POST /register":        16 << 10,
+				"POST /login":           16 << 10,
+				"POST /users":           16 << 10,
+				"POST /change-password": 16 << 10,
+			},
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"http://localhost:3000"},
+			AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowHeaders: []string{"Origin", "Authorization", "Content-Type", middleware.CSRFHeader, middleware.RequestIDHeader},
+			// client อ่าน Retry-After ของ 429 และ request id สำหรับแจ้งปัญหาได้
+			ExposeHeaders:    []string{middleware.RequestIDHeader, "Retry-After"},
+			AllowCredentials: true,
+			MaxAge:           12 * time.Hour,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			HSTSIncludeSubdomains: true,
+			FrameAncestors:        "'none'",
+			ReferrerPolicy:        "no-referrer",
+		},
+		Database: DatabaseConfig{
+			SSLMode: "disable",
+			Pool: PoolConfig{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Connect: ConnectRetryConfig{Attempts: 5, InitialBackoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second},
+		},
+		JWT: JWTConfig{AccessTTL: 15 * time.Minute, RefreshTTL: 7 * 24 * time.Hour},
+		// browser ถือว่า localhost เป็น secure context จึงใช้ Secure ตอนพัฒนาบน http ได้
+		Cookie: CookieConfig{Secure: true, SameSite: cookieutil.SameSiteLax, Path: "/", RefreshPath: "/refresh-token"},
+		Password: PasswordConfig{
+			Algorithm:  hashutil.AlgorithmArgon2id,
+			BcryptCost: 10,
+			Argon2: Argon2Config{
+				MemoryKiB:   hashutil.DefaultArgon2Params.Memory,
+				Iterations:  hashutil.DefaultArgon2Params.Iterations,
+				Parallelism: hashutil.DefaultArgon2Params.Parallelism,
+				SaltLength:  hashutil.DefaultArgon2Params.SaltLength,
+				KeyLength:   hashutil.DefaultArgon2Params.KeyLength,
+			},
+			Policy:                 defaultPasswordPolicy(),
+			HistoryCleanupInterval: 24 * time.Hour,
+		},
+		Log:     LogConfig{Level: "info", Format: logger.FormatJSON},
+		Auth:    AuthConfig{EmailLocalPart: emailutil.LocalPartLowercase},
+		Health:  HealthConfig{DatabaseTimeout: 2 * time.Second, RedisTimeout: time.Second},
+		Metrics: MetricsConfig{Enabled: true, Path: "/metrics"},
+		Tracing: TracingConfig{Exporter: tracing.ExporterNone, ServiceName: "ipxsandbox", SampleRatio: 1},
+	}
+}
+
+// Load อ่าน config ตามลำดับความสำคัญ ค่า default < ไฟล์ YAML (CONFIG_FILE) < environment
+// โดยไฟล์ .env จะถูกโหลดเข้า environment ก่อน แต่ไม่ทับตัวแปรที่ตั้งไว้อยู่แล้ว
+func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadDatabase อ่าน config แบบเดียวกับ Load แต่ตรวจเฉพาะส่วนของฐานข้อมูล
+// ใช้กับคำสั่งอย่าง cmd/migrate ที่ไม่ต้องมี JWT secret หรือ Redis
+func LoadDatabase() (*DatabaseConfig, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg.Database, nil
+}
+
+func load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadYAML(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+	cfg.Database.normalize()
+	return &cfg, nil
+}
+
+func loadYAML(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config) error {
+	e := &envReader{}
+
+	e.str("APP_ENV", &cfg.App.Env)
+
+	e.str("SERVER_ADDR", &cfg.Server.Addr)
+	e.duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	e.duration("SERVER_READ_HEADER_TIMEOUT", &cfg.Server.ReadHeaderTimeout)
+	e.duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	e.duration("SERVER_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	e.duration("SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+	e.duration("SERVER_SHUTDOWN_DELAY", &cfg.Server.ShutdownDelay)
+	e.duration("SERVER_REQUEST_TIMEOUT", &cfg.Server.RequestTimeout)
+	e.str("TLS_CERT_FILE", &cfg.Server.TLSCertFile)
+	e.str("TLS_KEY_FILE", &cfg.Server.TLSKeyFile)
+	e.int64("SERVER_MAX_BODY_BYTES", &cfg.Server.MaxBodyBytes)
+	e.int64Map("SERVER_BODY_LIMITS", &cfg.Server.BodyLimits)
+
+	e.list("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+	e.list("CORS_ALLOW_METHODS", &cfg.CORS.AllowMethods)
+	e.list("CORS_ALLOW_HEADERS", &cfg.CORS.AllowHeaders)
+	e.list("CORS_EXPOSE_HEADERS", &cfg.CORS.ExposeHeaders)
+	e.bool("CORS_ALLOW_CREDENTIALS", &cfg.CORS.AllowCredentials)
+	e.duration("CORS_MAX_AGE", &cfg.CORS.MaxAge)
+
+	e.duration("SECURITY_HSTS_MAX_AGE", &cfg.Security.HSTSMaxAge)
+	e.bool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", &cfg.Security.HSTSIncludeSubdomains)
+	e.bool("SECURITY_HSTS_PRELOAD", &cfg.Security.HSTSPreload)
+	e.str("SECURITY_FRAME_ANCESTORS", &cfg.Security.FrameAncestors)
+	e.str("SECURITY_REFERRER_POLICY", &cfg.Security.ReferrerPolicy)
+
+	e.str("DB_DRIVER", &cfg.Database.Driver)
+	e.str("DATABASE_URL", &cfg.Database.URL)
+	e.str("DB_HOST", &cfg.Database.Host)
+	e.str("DB_PORT", &cfg.Database.Port)
+	e.str("DB_USER", &cfg.Database.User)
+	e.str("DB_PASSWORD", &cfg.Database.Password)
+	e.str("DB_NAME", &cfg.Database.Name)
+	e.str("DB_SSLMODE", &cfg.Database.SSLMode)
+	e.bool("DB_AUTO_MIGRATE", &cfg.Database.AutoMigrate)
+	e.list("DB_REPLICA_URLS", &cfg.Database.ReplicaURLs)
+	e.int("DB_MAX_OPEN_CONNS", &cfg.Database.Pool.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &cfg.Database.Pool.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &cfg.Database.Pool.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &cfg.Database.Pool.ConnMaxIdleTime)
+	e.int("DB_CONNECT_ATTEMPTS", &cfg.Database.Connect.Attempts)
+	e.duration("DB_CONNECT_BACKOFF", &cfg.Database.Connect.InitialBackoff)
+	e.duration("DB_CONNECT_MAX_BACKOFF", &cfg.Database.Connect.MaxBackoff)
+
+	e.str("REDIS_ADDR", &cfg.Redis.Addr)
+	e.str("REDIS_PASSWORD", &cfg.Redis.Password)
+	e.int("REDIS_DB", &cfg.Redis.DB)
+
+	e.str("JWT_SECRET", &cfg.JWT.Secret)
+	e.duration("JWT_ACCESS_TTL", &cfg.JWT.AccessTTL)
+	e.duration("JWT_REFRESH_TTL", &cfg.JWT.RefreshTTL)
+
+	e.str("COOKIE_DOMAIN", &cfg.Cookie.Domain)
+	e.bool("COOKIE_SECURE", &cfg.Cookie.Secure)
+	e.str("COOKIE_SAMESITE", &cfg.Cookie.SameSite)
+	e.str("COOKIE_PATH", &cfg.Cookie.Path)
+	e.str("COOKIE_REFRESH_PATH", &cfg.Cookie.RefreshPath)
+	e.bool("COOKIE_HOST_PREFIX", &cfg.Cookie.HostPrefix)
+
+	e.str("PASSWORD_HASH_ALGORITHM", &cfg.Password.Algorithm)
+	e.int("BCRYPT_COST", &cfg.Password.BcryptCost)
+	e.uint32("ARGON2_MEMORY_KIB", &cfg.Password.Argon2.MemoryKiB)
+	e.uint32("ARGON2_ITERATIONS", &cfg.Password.Argon2.Iterations)
+	e.uint8("ARGON2_PARALLELISM", &cfg.Password.Argon2.Parallelism)
+	e.uint32("ARGON2_SALT_LENGTH", &cfg.Password.Argon2.SaltLength)
+	e.uint32("ARGON2_KEY_LENGTH", &cfg.Password.Argon2.KeyLength)
+	e.int("PASSWORD_MIN_LENGTH", &cfg.Password.Policy.MinLength)
+	e.int("PASSWORD_MAX_LENGTH", &cfg.Password.Policy.MaxLength)
+	e.bool("PASSWORD_REQUIRE_UPPER", &cfg.Password.Policy.RequireUpper)
+	e.bool("PASSWORD_REQUIRE_LOWER", &cfg.Password.Policy.RequireLower)
+	e.bool("PASSWORD_REQUIRE_DIGIT", &cfg.Password.Policy.RequireDigit)
+	e.bool("PASSWORD_REQUIRE_SPECIAL", &cfg.Password.Policy.RequireSpecial)
+	e.int("PASSWORD_MAX_REPEATED", &cfg.Password.Policy.MaxRepeated)
+	e.int("PASSWORD_HISTORY_DEPTH", &cfg.Password.Policy.HistoryDepth)
+	e.duration("PASSWORD_HISTORY_CLEANUP_INTERVAL", &cfg.Password.HistoryCleanupInterval)
+	e.durationMap("PASSWORD_MAX_AGE", &cfg.Password.MaxAge)
+
+	e.str("LOG_LEVEL", &cfg.Log.Level)
+	e.str("LOG_FORMAT", &cfg.Log.Format)
+
+	e.bool("REGISTRATION_CONCEAL_EXISTING", &cfg.Auth.ConcealRegistration)
+	e.str("EMAIL_LOCAL_PART_POLICY", &cfg.Auth.EmailLocalPart)
+
+	e.str("AUDIT_LOG_FILE", &cfg.Audit.LogFile)
+
+	e.duration("HEALTH_DB_TIMEOUT", &cfg.Health.DatabaseTimeout)
+	e.duration("HEALTH_REDIS_TIMEOUT", &cfg.Health.RedisTimeout)
+
+	e.bool("METRICS_ENABLED", &cfg.Metrics.Enabled)
+	e.str("METRICS_PATH", &cfg.Metrics.Path)
+
+	e.str("OTEL_TRACES_EXPORTER", &cfg.Tracing.Exporter)
+	e.str("OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	e.str("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", &cfg.Tracing.Endpoint)
+	e.float64("OTEL_TRACES_SAMPLER_ARG", &cfg.Tracing.SampleRatio)
+
+	return errors.Join(e.errs...)
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(name, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+
+	switch c.App.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		errs = append(errs, fmt.Errorf("APP_ENV must be one of %s, %s, %s", EnvDevelopment, EnvStaging, EnvProduction))
+	}
+
+	required("SERVER_ADDR", c.Server.Addr)
+	if c.Server.ReadTimeout < 0 || c.Server.ReadHeaderTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		errs = append(errs, errors.New("server timeouts must not be negative"))
+	}
+	if c.Server.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("SERVER_SHUTDOWN_DELAY must not be negative"))
+	}
+	if c.Server.RequestTimeout < 0 {
+		errs = append(errs, errors.New("SERVER_REQUEST_TIMEOUT must not be negative"))
+	}
+	if c.Server.RequestTimeout > 0 && c.Server.WriteTimeout > 0 && c.Server.RequestTimeout >= c.Server.WriteTimeout {
+		errs = append(errs, errors.New("SERVER_REQUEST_TIMEOUT must be shorter than SERVER_WRITE_TIMEOUT so the timeout response can still be written"))
+	}
+	if c.Server.MaxBodyBytes < 0 {
+		errs = append(errs, errors.New("SERVER_MAX_BODY_BYTES must not be negative"))
+	}
+	for _, route := range slices.Sorted(maps.Keys(c.Server.BodyLimits)) {
+		limit := c.Server.BodyLimits[route]
+		if method, path, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("SERVER_BODY_LIMITS: route %q must look like \"POST /path\"", route))
+		}
+		if limit < 0 {
+			errs = append(errs, fmt.Errorf("SERVER_BODY_LIMITS: limit of %q must not be negative", route))
+		}
+	}
+	if c.Health.DatabaseTimeout <= 0 || c.Health.RedisTimeout <= 0 {
+		errs = append(errs, errors.New("HEALTH_DB_TIMEOUT and HEALTH_REDIS_TIMEOUT must be positive"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("SERVER_SHUTDOWN_TIMEOUT must be positive"))
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	for name, path := range map[string]string{"TLS_CERT_FILE": c.Server.TLSCertFile, "TLS_KEY_FILE": c.Server.TLSKeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if err := c.CORS.MiddlewareConfig().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("CORS: %w", err))
+	}
+	if err := c.Security.MiddlewareConfig().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("security headers: %w", err))
+	}
+	if c.App.Env == EnvProduction && c.Security.HSTSMaxAge <= 0 {
+		errs = append(errs, errors.New("SECURITY_HSTS_MAX_AGE must be positive in production"))
+	}
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		errs = append(errs, errors.New("METRICS_PATH must start with /"))
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_EXPORTER must be one of %s, %s, %s", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP))
+	}
+	if c.Tracing.Exporter != tracing.ExporterNone {
+		required("OTEL_SERVICE_NAME", c.Tracing.ServiceName)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1"))
+	}
+
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	required("REDIS_ADDR", c.Redis.Addr)
+
+	if err := validateJWTSecret(c.JWT.Secret); err != nil {
+		errs = append(errs, err)
+	}
+	if c.JWT.AccessTTL <= 0 || c.JWT.RefreshTTL <= 0 {
+		errs = append(errs, errors.New("JWT_ACCESS_TTL and JWT_REFRESH_TTL must be positive"))
+	}
+	if c.JWT.AccessTTL >= c.JWT.RefreshTTL {
+		errs = append(errs, errors.New("JWT_ACCESS_TTL must be shorter than JWT_REFRESH_TTL"))
+	}
+
+	if _, err := cookieutil.New(c.CookiePolicyConfig()); err != nil {
+		errs = append(errs, fmt.Errorf("cookies: %w", err))
+	}
+	if c.App.Env == EnvProduction && !c.Cookie.Secure {
+		errs = append(errs, errors.New("COOKIE_SECURE must be true in production"))
+	}
+
+	if _, err := hashutil.New(c.Password.HasherConfig()); err != nil {
+		errs = append(errs, fmt.Errorf("password hashing: %w", err))
+	}
+	if err := c.Password.PolicyConfig().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("password policy: %w", err))
+	}
+	if c.App.Env == EnvProduction && c.Password.Policy.MinLength < minProductionPasswordLength {
+		errs = append(errs, fmt.Errorf("PASSWORD_MIN_LENGTH must be at least %d in production", minProductionPasswordLength))
+	}
+	// bcrypt รับรหัสผ่านได้ไม่เกิน 72 byte ความยาวที่เกินจาก 72 ตัวอักษรจึงไม่มีทางผ่าน MaxBytes ได้
+	if c.Password.usesBcrypt() && c.Password.Policy.MaxLength > hashutil.BcryptMaxBytes {
+		errs = append(errs, fmt.Errorf("PASSWORD_MAX_LENGTH must be at most %d with bcrypt", hashutil.BcryptMaxBytes))
+	}
+	if c.Password.HistoryCleanupInterval < 0 {
+		errs = append(errs, errors.New("PASSWORD_HISTORY_CLEANUP_INTERVAL must not be negative"))
+	}
+	for _, role := range slices.Sorted(maps.Keys(c.Password.MaxAge)) {
+		if role != entity.RoleUser && role != entity.RoleAdmin {
+			errs = append(errs, fmt.Errorf("PASSWORD_MAX_AGE has unknown role %q", role))
+		}
+		if c.Password.MaxAge[role] <= 0 {
+			errs = append(errs, fmt.Errorf("PASSWORD_MAX_AGE for %s must be positive", role))
+		}
+	}
+	if _, err := logger.New(c.Log.LoggerConfig()); err != nil {
+		errs = append(errs, fmt.Errorf("logging: %w", err))
+	}
+	if _, err := emailutil.New(c.Auth.EmailLocalPart); err != nil {
+		errs = append(errs, fmt.Errorf("EMAIL_LOCAL_PART_POLICY: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d DatabaseConfig) Validate() error {
+	switch d.driver() {
+	case DriverPostgres, DriverMySQL, DriverSQLite:
+	default:
+		return fmt.Errorf("DB_DRIVER must be one of %s, %s, %s", DriverPostgres, DriverMySQL, DriverSQLite)
+	}
+
+	var errs []error
+	required := []struct{ name, value string }{
+		{"DB_HOST", d.Host},
+		{"DB_USER", d.User},
+		{"DB_NAME", d.Name},
+	}
+	switch {
+	case d.URL != "":
+		required = nil
+	case d.driver() == DriverSQLite:
+		// sqlite ใช้ DB_NAME เป็น path ของไฟล์
+		required = required[2:]
+	}
+	for _, f := range required {
+		if strings.TrimSpace(f.value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required (or set DATABASE_URL)", f.name))
+		}
+	}
+	if len(errs) == 0 {
+		if _, err := d.DSN(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(d.ReplicaURLs) > 0 && d.driver() == DriverSQLite {
+		errs = append(errs, errors.New("DB_REPLICA_URLS is not supported with sqlite"))
+	}
+	for i, replica := range d.replicas() {
+		if _, err := replica.DSN(); err != nil {
+			errs = append(errs, fmt.Errorf("DB_REPLICA_URLS[%d]: %w", i, err))
+		}
+	}
+
+	p := d.Pool
+	if p.MaxOpenConns < 0 || p.MaxIdleConns < 0 || p.ConnMaxLifetime < 0 || p.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database pool settings must not be negative"))
+	}
+	if p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS"))
+	}
+	if d.Connect.Attempts < 1 {
+		errs = append(errs, errors.New("DB_CONNECT_ATTEMPTS must be at least 1"))
+	}
+	if d.Connect.InitialBackoff <= 0 || d.Connect.MaxBackoff < d.Connect.InitialBackoff {
+		errs = append(errs, errors.New("DB_CONNECT_BACKOFF must be positive and not exceed DB_CONNECT_MAX_BACKOFF"))
+	}
+	return errors.Join(errs...)
+}
+
+func validateJWTSecret(secret string) error {
+	if secret == "" {
+		return errors.New("JWT_SECRET is required")
+	}
+	if len(secret) < minJWTSecretLength {
+		return fmt.Errorf("JWT_SECRET must be at least %d bytes, got %d", minJWTSecretLength, len(secret))
+	}
+	// กันค่าอย่าง "aaaa..." หรือ "123412341234..." ที่ยาวพอแต่เดาได้ง่าย
+	distinct := make(map[rune]struct{})
+	for _, r := range secret {
+		distinct[r] = struct{}{}
+	}
+	if len(distinct) < minJWTSecretDistinct {
+		return errors.New("JWT_SECRET is too weak, generate a random value (e.g. openssl rand -base64 48)")
+	}
+	return nil
+}
+
+// PolicyConfig ตั้ง MaxBytes ตาม algorithm ที่ใช้ เพราะ bcrypt ปฏิเสธรหัสผ่านที่ยาวเกิน 72 byte
+// ซึ่งรหัสผ่านภาษาไทยถึงขีดนี้ได้ตั้งแต่ 25 ตัวอักษร
+func (p PasswordConfig) PolicyConfig() passwordpolicy.Policy {
+	policy := passwordpolicy.Policy{
+		MinLength:      p.Policy.MinLength,
+		MaxLength:      p.Policy.MaxLength,
+		RequireUpper:   p.Policy.RequireUpper,
+		RequireLower:   p.Policy.RequireLower,
+		RequireDigit:   p.Policy.RequireDigit,
+		RequireSpecial: p.Policy.RequireSpecial,
+		MaxRepeated:    p.Policy.MaxRepeated,
+		HistoryDepth:   p.Policy.HistoryDepth,
+	}
+	if p.usesBcrypt() {
+		policy.MaxBytes = hashutil.BcryptMaxBytes
+	}
+	return policy
+}
+
+// usesBcrypt เทียบชื่อ algorithm แบบเดียวกับ hashutil.New คือไม่สนตัวพิมพ์ และค่าว่างหมายถึง bcrypt
+func (p PasswordConfig) usesBcrypt() bool {
+	switch strings.ToLower(p.Algorithm) {
+	case "", hashutil.AlgorithmBcrypt:
+		return true
+	}
+	return false
+}
+
+func defaultPasswordPolicy() PasswordPolicyConfig {
+	d := passwordpolicy.Default()
+	return PasswordPolicyConfig{
+		MinLength:      d.MinLength,
+		MaxLength:      d.MaxLength,
+		RequireUpper:   d.RequireUpper,
+		RequireLower:   d.RequireLower,
+		RequireDigit:   d.RequireDigit,
+		RequireSpecial: d.RequireSpecial,
+		MaxRepeated:    d.MaxRepeated,
+		HistoryDepth:   d.HistoryDepth,
+	}
+}
+
+func (p PasswordConfig) HasherConfig() hashutil.Config {
+	return hashutil.Config{
+		Algorithm:  p.Algorithm,
+		BcryptCost: p.BcryptCost,
+		Argon2: hashutil.Argon2Params{
+			Memory:      p.Argon2.MemoryKiB,
+			Iterations:  p.Argon2.Iterations,
+			Parallelism: p.Argon2.Parallelism,
+			SaltLength:  p.Argon2.SaltLength,
+			KeyLength:   p.Argon2.KeyLength,
+		},
+	}
+}
+
+func (c CORSConfig) MiddlewareConfig() middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowOrigins:     c.AllowOrigins,
+		AllowMethods:     c.AllowMethods,
+		AllowHeaders:     c.AllowHeaders,
+		ExposeHeaders:    c.ExposeHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	}
+}
+
+func (s SecurityConfig) MiddlewareConfig() middleware.SecurityHeadersConfig {
+	return middleware.SecurityHeadersConfig{
+		HSTSMaxAge:            s.HSTSMaxAge,
+		HSTSIncludeSubdomains: s.HSTSIncludeSubdomains,
+		HSTSPreload:           s.HSTSPreload,
+		FrameAncestors:        s.FrameAncestors,
+		ReferrerPolicy:        s.ReferrerPolicy,
+	}
+}
+
+func (c *Config) CookiePolicyConfig() cookieutil.Config {
+	return cookieutil.Config{
+		Domain:        c.Cookie.Domain,
+		Secure:        c.Cookie.Secure,
+		SameSite:      c.Cookie.SameSite,
+		Path:          c.Cookie.Path,
+		RefreshPath:   c.Cookie.RefreshPath,
+		HostPrefix:    c.Cookie.HostPrefix,
+		AccessMaxAge:  c.JWT.AccessTTL,
+		RefreshMaxAge: c.JWT.RefreshTTL,
+	}
+}
+
+func (s ServerConfig) HTTPConfig() server.Config {
+	return server.Config{
+		Addr:              s.Addr,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+		ShutdownTimeout:   s.ShutdownTimeout,
+		ShutdownDelay:     s.ShutdownDelay,
+		TLSCertFile:       s.TLSCertFile,
+		TLSKeyFile:        s.TLSKeyFile,
+	}
+}
+
+func (l LogConfig) LoggerConfig() logger.Config {
+	return logger.Config{Level: l.Level, Format: l.Format}
+}
+
+func (t TracingConfig) TracerConfig() tracing.Config {
+	return tracing.Config{
+		Exporter:    t.Exporter,
+		ServiceName: t.ServiceName,
+		Endpoint:    t.Endpoint,
+		SampleRatio: t.SampleRatio,
+	}
+}
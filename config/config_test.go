@@ -0,0 +1,346 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "k3Jx9mQ2vL8pR5tW1zY7bN4cF6hD0gSa"
+
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USER", "app")
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("REDIS_ADDR", "localhost:6379")
+	t.Setenv("JWT_SECRET", testSecret)
+}
+
+func TestLoadDefaultsAndEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("JWT_ACCESS_TTL", "5m")
+	t.Setenv("REGISTRATION_CONCEAL_EXISTING", "true")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, EnvDevelopment, cfg.App.Env)
+	assert.Equal(t, "5432", cfg.Database.Port)
+	assert.Equal(t, 5*time.Minute, cfg.JWT.AccessTTL)
+	assert.Equal(t, 7*24*time.Hour, cfg.JWT.RefreshTTL)
+	assert.True(t, cfg.Auth.ConcealRegistration)
+}
+
+func TestLoadYAMLIsOverriddenByEnv(t *testing.T) {
+	setRequiredEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  host: yaml-host
+  port: "6543"
+log:
+  level: debug
+`), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DB_HOST", "env-host")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "env-host", cfg.Database.Host)
+	assert.Equal(t, "6543", cfg.Database.Port)
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestLoadRejectsUnknownYAMLKeys(t *testing.T) {
+	setRequiredEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("databse:\n  host: typo\n"), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+
+	_, err := Load()
+	assert.Error(t, err)
+}
+
+func TestValidateJWTSecret(t *testing.T) {
+	tests := map[string]string{
+		"empty":    "",
+		"short":    "short-secret",
+		"repeated": strings.Repeat("a", 48),
+		"pattern":  strings.Repeat("1234", 12),
+	}
+	for name, secret := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, validateJWTSecret(secret))
+		})
+	}
+
+	assert.NoError(t, validateJWTSecret(testSecret))
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	err := cfg.Validate()
+	require.Error(t, err)
+
+	for _, want := range []string{"DB_HOST", "DB_USER", "DB_NAME", "REDIS_ADDR", "JWT_SECRET"} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestValidateTLSFilesMustBeSetTogether(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("TLS_CERT_FILE", filepath.Join(t.TempDir(), "cert.pem"))
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+}
+
+func TestValidateTracing(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OTEL_TRACES_EXPORTER", "jaeger")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "1.5")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OTEL_TRACES_EXPORTER must be one of")
+	assert.Contains(t, err.Error(), "OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+}
+
+func TestValidateRequestTimeoutShorterThanWriteTimeout(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SERVER_REQUEST_TIMEOUT", "30s")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SERVER_REQUEST_TIMEOUT must be shorter than SERVER_WRITE_TIMEOUT")
+}
+
+func TestDatabaseDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     DatabaseConfig
+		driver  string
+		wantDSN string
+	}{
+		{
+			name:    "postgres fields",
+			cfg:     DatabaseConfig{Host: "db", User: "app", Password: "pw", Name: "app", SSLMode: "disable"},
+			driver:  DriverPostgres,
+			wantDSN: "host=db user=app password=pw dbname=app port=5432 sslmode=disable",
+		},
+		{
+			name:    "postgres url",
+			cfg:     DatabaseConfig{URL: "postgres://app:pw@db:5432/app?sslmode=require"},
+			driver:  DriverPostgres,
+			wantDSN: "postgres://app:pw@db:5432/app?sslmode=require",
+		},
+		{
+			name:    "mysql url",
+			cfg:     DatabaseConfig{URL: "mysql://app:pw@db/app"},
+			driver:  DriverMySQL,
+			wantDSN: "app:pw@tcp(db:3306)/app?parseTime=true&charset=utf8mb4",
+		},
+		{
+			name:    "mysql fields",
+			cfg:     DatabaseConfig{Driver: DriverMySQL, Host: "db", User: "app", Password: "pw", Name: "app"},
+			driver:  DriverMySQL,
+			wantDSN: "app:pw@tcp(db:3306)/app?parseTime=true&charset=utf8mb4",
+		},
+		{
+			name:    "sqlite url",
+			cfg:     DatabaseConfig{URL: "sqlite:///var/lib/app.db"},
+			driver:  DriverSQLite,
+			wantDSN: "file:/var/lib/app.db?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.driver, tt.cfg.Dialect())
+			dsn, err := tt.cfg.DSN()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDSN, dsn)
+		})
+	}
+}
+
+func TestLoadSQLiteNeedsOnlyName(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "app.db"))
+
+	cfg, err := LoadDatabase()
+	require.NoError(t, err)
+	assert.Equal(t, DriverSQLite, cfg.Driver)
+	assert.Empty(t, cfg.Port)
+}
+
+func TestValidatePoolAndReplicas(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DB_MAX_OPEN_CONNS", "5")
+	t.Setenv("DB_MAX_IDLE_CONNS", "10")
+	t.Setenv("DB_CONNECT_ATTEMPTS", "0")
+	t.Setenv("DB_REPLICA_URLS", "postgres://app@replica-1/app, postgres://app@replica-2/app")
+
+	cfg, err := Load()
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+	assert.Contains(t, err.Error(), "DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS")
+	assert.Contains(t, err.Error(), "DB_CONNECT_ATTEMPTS must be at least 1")
+	assert.NotContains(t, err.Error(), "DB_REPLICA_URLS")
+}
+
+func TestRetry(t *testing.T) {
+	cfg := ConnectRetryConfig{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	errDown := errors.New("connection refused")
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		calls := 0
+		err := retry(context.Background(), cfg, func() error {
+			calls++
+			if calls < 3 {
+				return errDown
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after attempts", func(t *testing.T) {
+		calls := 0
+		err := retry(context.Background(), cfg, func() error {
+			calls++
+			return errDown
+		})
+		assert.ErrorIs(t, err, errDown)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops when context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := retry(ctx, ConnectRetryConfig{Attempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}, func() error {
+			calls++
+			cancel()
+			return errDown
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, errDown)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestValidateCookies(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("COOKIE_SECURE", "false")
+	t.Setenv("COOKIE_SAMESITE", "none")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "COOKIE_SECURE must be true in production")
+	assert.Contains(t, err.Error(), "SameSite=None requires Secure cookies")
+}
+
+func TestLoadCORSAndSecurityHeaders(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://app.example.com, https://*.preview.example.com")
+	t.Setenv("CORS_MAX_AGE", "10m")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com", "https://*.preview.example.com"}, cfg.CORS.AllowOrigins)
+	assert.Equal(t, 10*time.Minute, cfg.CORS.MaxAge)
+
+	t.Setenv("CORS_ALLOW_ORIGINS", "*")
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("SECURITY_HSTS_MAX_AGE", "0s")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CORS: invalid CORS origin")
+	assert.Contains(t, err.Error(), "SECURITY_HSTS_MAX_AGE must be positive in production")
+}
+
+func TestLoadBodyLimits(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SERVER_BODY_LIMITS", "POST /register=4096, POST /upload=0")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<20), cfg.Server.MaxBodyBytes)
+	assert.Equal(t, map[string]int64{"POST /register": 4096, "POST /upload": 0}, cfg.Server.BodyLimits)
+
+	t.Setenv("SERVER_BODY_LIMITS", "/register=4096")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `route "/register" must look like "POST /path"`)
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_REQUIRE_SPECIAL", "false")
+	t.Setenv("PASSWORD_MAX_REPEATED", "3")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	policy := cfg.Password.PolicyConfig()
+	assert.Equal(t, 12, policy.MinLength)
+	assert.Equal(t, 72, policy.MaxLength)
+	assert.False(t, policy.RequireSpecial)
+	assert.True(t, policy.RequireUpper)
+	assert.Equal(t, 3, policy.MaxRepeated)
+	assert.Equal(t, 24*time.Hour, cfg.Password.HistoryCleanupInterval)
+	assert.Empty(t, cfg.Password.MaxAge)
+
+	t.Setenv("PASSWORD_MAX_AGE", "admin=2160h")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"admin": 2160 * time.Hour}, cfg.Password.MaxAge)
+
+	// ขีดจำกัดของ bcrypt นับเป็น byte ส่วน argon2id ไม่จำกัด
+	assert.Zero(t, policy.MaxBytes)
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 72, cfg.Password.PolicyConfig().MaxBytes)
+
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("PASSWORD_MIN_LENGTH", "6")
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	t.Setenv("PASSWORD_MAX_LENGTH", "100")
+	t.Setenv("PASSWORD_HISTORY_CLEANUP_INTERVAL", "-1h")
+	t.Setenv("PASSWORD_MAX_AGE", "admin=0s,owner=720h")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PASSWORD_MIN_LENGTH must be at least 8 in production")
+	assert.Contains(t, err.Error(), "PASSWORD_MAX_LENGTH must be at most 72 with bcrypt")
+	assert.Contains(t, err.Error(), "PASSWORD_HISTORY_CLEANUP_INTERVAL must not be negative")
+	assert.Contains(t, err.Error(), "PASSWORD_MAX_AGE for admin must be positive")
+	assert.Contains(t, err.Error(), `PASSWORD_MAX_AGE has unknown role "owner"`)
+}
+
+func TestBcryptLimitsIgnoreAlgorithmCase(t *testing.T) {
+	for _, algorithm := range []string{"BCRYPT", ""} {
+		t.Run(algorithm, func(t *testing.T) {
+			setRequiredEnv(t)
+			cfg, err := Load()
+			require.NoError(t, err)
+			cfg.Password.Algorithm = algorithm
+
+			assert.Equal(t, 72, cfg.Password.PolicyConfig().MaxBytes)
+			cfg.Password.Policy.MaxLength = 100
+			err = cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "PASSWORD_MAX_LENGTH must be at most 72 with bcrypt")
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader ทับค่าใน config ด้วยตัวแปร environment ที่ตั้งไว้ และเก็บ error ทั้งหมดไว้รายงานทีเดียว
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	return v, ok && v != ""
+}
+
+func (e *envReader) fail(key string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("invalid %s: %w", key, err))
+}
+
+func (e *envReader) str(key string, dst *string) {
+	if v, ok := e.lookup(key); ok {
+		*dst = v
+	}
+}
+
+// list อ่านค่าที่คั่นด้วย comma และตัดช่องว่างกับค่าว่างทิ้ง
+func (e *envReader) list(key string, dst *[]string) {
+	if v, ok := e.lookup(key); ok {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+// int64Map อ่านค่าในรูป key=value คั่นด้วย comma เช่น "POST /login=4096,POST /users=16384"
+func (e *envReader) int64Map(key string, dst *map[string]int64) {
+	readMap(e, key, dst, func(v string) (int64, error) { return strconv.ParseInt(v, 10, 64) })
+}
+
+// durationMap อ่านค่าในรูปเดียวกับ int64Map เช่น "admin=2160h"
+func (e *envReader) durationMap(key string, dst *map[string]time.Duration) {
+	readMap(e, key, dst, time.ParseDuration)
+}
+
+func readMap[T any](e *envReader, key string, dst *map[string]T, parse func(string) (T, error)) {
+	v, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	m := make(map[string]T)
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		k, raw, found := strings.Cut(item, "=")
+		if !found {
+			e.fail(key, fmt.Errorf("%q is not in key=value form", item))
+			return
+		}
+		n, err := parse(strings.TrimSpace(raw))
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		m[strings.TrimSpace(k)] = n
+	}
+	*dst = m
+}
+
+func (e *envReader) int(key string, dst *int) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) int64(key string, dst *int64) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) uint32(key string, dst *uint32) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = uint32(n)
+	}
+}
+
+func (e *envReader) uint8(key string, dst *uint8) {
+	if v, ok := e.lookup(key); ok {
+		n, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = uint8(n)
+	}
+}
+
+func (e *envReader) float64(key string, dst *float64) {
+	if v, ok := e.lookup(key); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	if v, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envReader) duration(key string, dst *time.Duration) {
+	if v, ok := e.lookup(key); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.fail(key, err)
+			return
+		}
+		*dst = d
+	}
+}
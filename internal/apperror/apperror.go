@@ -0,0 +1,170 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindRateLimited  Kind = "rate_limited"
+	KindTimeout      Kind = "timeout"
+	KindTooLarge     Kind = "payload_too_large"
+)
+
+func (k Kind) Status() int {
+	switch k {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindTimeout:
+		return http.StatusServiceUnavailable
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+// Error คือ error ของ domain ที่บอกได้ว่าควรตอบ client ด้วย status อะไร
+// Message ส่งถึง client ได้ ส่วน Err เป็นสาเหตุจริงที่ใช้ log เท่านั้น
+type Error struct {
+	Kind       Kind
+	Message    string
+	Fields     []FieldError
+	RetryAfter time.Duration
+	Err        error
+
+	// sentinel ชี้ไปยัง error ต้นฉบับของ copy ที่ได้จาก WithCause หรือ WithFields
+	sentinel *Error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is เทียบว่ามาจาก sentinel ตัวเดียวกัน เพื่อให้ errors.Is ใช้ได้แม้ error จะถูก copy ผ่าน WithCause
+// sentinel สองตัวที่ Kind และ Message เหมือนกันจึงไม่ถือว่าเป็น error เดียวกัน
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.origin() == e.origin()
+}
+
+func (e *Error) origin() *Error {
+	if e.sentinel != nil {
+		return e.sentinel
+	}
+	return e
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	c.sentinel = e.origin()
+	return &c
+}
+
+func (e *Error) WithCause(err error) *Error {
+	c := e.clone()
+	c.Err = err
+	return c
+}
+
+// WithFields คืน copy ที่ใช้ Fields ชุดใหม่ เช่น Fields ที่แปลเป็นภาษาของ request แล้ว
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := e.clone()
+	c.Fields = fields
+	return c
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return New(KindNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(KindConflict, message)
+}
+
+// FieldError บอกว่า field ไหนผิดด้วยเงื่อนไขอะไร ให้ frontend เลือกข้อความเองจาก Code และ Params
+// หรือใช้ Message ที่แปลแล้วก็ได้ Field เป็น path แบบ JSON เช่น address.street หรือ items[0].name
+type FieldError struct {
+	Field   string            `json:"field"`
+	Code    string            `json:"code"`
+	Params  map[string]string `json:"params,omitempty"`
+	Message string            `json:"message"`
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func Unauthorized(message string) *Error {
+	return New(KindUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(KindForbidden, message)
+}
+
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindRateLimited, Message: message, RetryAfter: retryAfter}
+}
+
+func Timeout(message string) *Error {
+	return New(KindTimeout, message)
+}
+
+func TooLarge(message string) *Error {
+	return New(KindTooLarge, message)
+}
+
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Message: "internal server error", Err: err}
+}
+
+// ErrTimeout ใช้เมื่อ request ทำงานเกิน deadline ที่ตั้งไว้
+var ErrTimeout = Timeout("request timed out")
+
+// From คืน *Error ที่อยู่ใน chain ของ err ถ้าไม่มีจะถือว่าเป็น internal error
+// ยกเว้น context.DeadlineExceeded ที่ถือว่าเป็น timeout
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout.WithCause(err)
+	}
+	return Internal(err)
+}
+
+func KindOf(err error) Kind {
+	return From(err).Kind
+}
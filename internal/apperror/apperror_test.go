@@ -0,0 +1,48 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMatchesSentinelAfterWithCause(t *testing.T) {
+	errUserNotFound := NotFound("user not found")
+	err := fmt.Errorf("lookup: %w", errUserNotFound.WithCause(errors.New("record not found")))
+
+	assert.ErrorIs(t, err, errUserNotFound)
+	assert.NotErrorIs(t, err, NotFound("post not found"))
+	assert.Equal(t, KindNotFound, KindOf(err))
+}
+
+func TestIsDistinguishesSentinelsWithSameMessage(t *testing.T) {
+	errInvalidEmail := Validation("validation failed", FieldError{Field: "email", Code: "email"})
+	errWrongPassword := Validation("validation failed", FieldError{Field: "current_password", Code: "invalid"})
+
+	assert.NotErrorIs(t, errInvalidEmail, errWrongPassword)
+	assert.NotErrorIs(t, errInvalidEmail.WithCause(errors.New("bad")), errWrongPassword)
+	assert.ErrorIs(t, errWrongPassword.WithFields(FieldError{Field: "current_password", Code: "invalid", Message: "รหัสผ่านไม่ถูกต้อง"}), errWrongPassword)
+}
+
+func TestFromWrapsUnknownErrorsAsInternal(t *testing.T) {
+	cause := errors.New(`pq: relation "users" does not exist`)
+	appErr := From(cause)
+
+	assert.Equal(t, KindInternal, appErr.Kind)
+	assert.Equal(t, http.StatusInternalServerError, appErr.Kind.Status())
+	assert.NotContains(t, appErr.Problem("/users", "").Detail, "relation")
+	assert.ErrorIs(t, appErr, cause)
+}
+
+func TestFromMapsDeadlineExceededToTimeout(t *testing.T) {
+	err := fmt.Errorf("find user: %w", context.DeadlineExceeded)
+	appErr := From(err)
+
+	assert.ErrorIs(t, appErr, ErrTimeout)
+	assert.Equal(t, http.StatusServiceUnavailable, appErr.Kind.Status())
+	assert.ErrorIs(t, appErr, context.DeadlineExceeded)
+}
@@ -0,0 +1,32 @@
+package apperror
+
+import "net/http"
+
+const ProblemContentType = "application/problem+json"
+
+// Problem คือ response body ตาม RFC 7807 ใช้กับ error ของทุก handler
+// Errors มีเฉพาะ validation error โดยเรียงตามลำดับที่ตรวจพบ
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Kind         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func (e *Error) Problem(instance, requestID string) Problem {
+	status := e.Kind.Status()
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Kind,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/logger"
+)
+
+const (
+	ActionRegister                  = "auth.register"
+	ActionLogin                     = "auth.login"
+	ActionTokenRefresh              = "auth.token_refresh"
+	ActionPasswordChange            = "auth.password_change"
+	ActionUserList                  = "user.list"
+	ActionUserCreate                = "user.create"
+	ActionUserRequirePasswordChange = "user.require_password_change"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeBlocked = "blocked"
+)
+
+// Sink คือปลายทางที่ audit event ถูกเขียนลงไป เช่นตารางในฐานข้อมูลหรือไฟล์
+type Sink interface {
+	Write(ctx context.Context, event entity.AuditEvent) error
+}
+
+type Recorder interface {
+	Record(ctx context.Context, event entity.AuditEvent)
+}
+
+type recorder struct {
+	sinks []Sink
+	now   func() time.Time
+}
+
+func NewRecorder(sinks ...Sink) Recorder {
+	return &recorder{sinks: sinks, now: time.Now}
+}
+
+// Record เขียน event ไปทุก sink ถ้า sink ใดเขียนไม่สำเร็จจะ log ไว้แต่ไม่ทำให้ request ล้มเหลว
+// event ต้องถูกเขียนแม้ client ตัดการเชื่อมต่อหรือ request หมดเวลา จึงไม่ส่งต่อการ cancel ของ ctx
+func (r *recorder) Record(ctx context.Context, event entity.AuditEvent) {
+	ctx = context.WithoutCancel(ctx)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = r.now().UTC()
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			logger.FromContext(ctx).Error("Failed to write audit event",
+				slog.String("action", event.Action),
+				slog.String("outcome", event.Outcome),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Record(context.Context, entity.AuditEvent) {}
+
+// Nop ใช้ในเทสหรือเมื่อไม่ต้องการเก็บ audit log
+func Nop() Recorder {
+	return nopRecorder{}
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/ipxsandbox/internal/entity"
+	auditRepository "github.com/ipxsandbox/internal/repository/audit"
+)
+
+type gormSink struct {
+	repo auditRepository.Repository
+}
+
+func NewGormSink(repo auditRepository.Repository) Sink {
+	return &gormSink{repo: repo}
+}
+
+func (s *gormSink) Write(ctx context.Context, event entity.AuditEvent) error {
+	return s.repo.Create(ctx, event)
+}
+
+// JSONLinesSink เขียน event ละหนึ่งบรรทัดต่อท้ายไฟล์ เหมาะกับส่งต่อให้ log shipper
+type JSONLinesSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func NewJSONLinesSink(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesSink{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *JSONLinesSink) Write(ctx context.Context, event entity.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(event)
+}
+
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
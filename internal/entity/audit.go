@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// AuditEvent เป็นบันทึกแบบ append-only ห้ามแก้ไขหรือลบหลังจากเขียนแล้ว
+type AuditEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+	Action    string    `json:"action" gorm:"not null;index"`
+	Outcome   string    `json:"outcome" gorm:"not null;index"`
+	ActorID   *uint     `json:"actor_id,omitempty" gorm:"index"`
+	Target    string    `json:"target,omitempty" gorm:"index"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// PasswordHistory คือ hash ของรหัสผ่านเดิมที่ถูกเปลี่ยนไปแล้ว ใช้กันการนำรหัสผ่านเก่ากลับมาใช้
+type PasswordHistory struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Password  string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
@@ -1,14 +1,35 @@
 package entity
 
+import "time"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-    ID       uint   `json:"id" gorm:"primaryKey"`
-    Name     string `json:"name" gorm:"not null" validate:"required,max=20"`
-    Email    string `json:"email" gorm:"unique;not null" validate:"required,email"`
-    Password string `json:"password" gorm:"not null" validate:"required,min=8,password"`
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"not null"`
+	Email    string `json:"email" gorm:"unique;not null"`
+	Password string `json:"password" gorm:"not null"`
+	Role     string `json:"-" gorm:"not null;default:user"`
+	// Locale คือภาษาที่ user เลือกไว้ ว่างไว้เพื่อใช้ภาษาจาก Accept-Language
+	Locale string `json:"locale" gorm:"not null;default:''"`
+	// PasswordChangedAt ใช้คำนวณว่ารหัสผ่านหมดอายุตาม role หรือยัง
+	PasswordChangedAt time.Time `json:"password_changed_at" gorm:"not null;autoCreateTime"`
+	// MustChangePassword ถูกตั้งเมื่อ admin สั่งให้เปลี่ยนรหัสผ่าน และล้างเมื่อ user เปลี่ยนแล้ว
+	MustChangePassword bool `json:"must_change_password" gorm:"not null;default:false"`
 }
 
 type UserResponse struct {
-    ID    uint   `json:"id"`
-    Name  string `json:"name"`
-    Email string `json:"email"`
-}
\ No newline at end of file
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Locale string `json:"locale"`
+}
+
+// Response คือข้อมูลของ user ที่ส่งให้ client ได้ ไม่มี hash ของรหัสผ่านและสถานะการเปลี่ยนรหัสผ่าน
+func (u User) Response() UserResponse {
+	return UserResponse{ID: u.ID, Name: u.Name, Email: u.Email, Role: u.Role, Locale: u.Locale}
+}
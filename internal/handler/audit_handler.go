@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/middleware"
+	auditRepository "github.com/ipxsandbox/internal/repository/audit"
+	auditUsecase "github.com/ipxsandbox/internal/usecase/audit"
+	customValidator "github.com/ipxsandbox/internal/validator"
+)
+
+type AuditHandler struct {
+	uc auditUsecase.Usecase
+}
+
+func NewAuditHandler(uc auditUsecase.Usecase) *AuditHandler {
+	return &AuditHandler{uc: uc}
+}
+
+// ListEvents GET /admin/audit?action=&outcome=&actor_id=&target=&from=&to=&page=&page_size=
+// from และ to เป็นเวลาแบบ RFC 3339
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	filter := auditRepository.Filter{
+		Action:  c.Query("action"),
+		Outcome: c.Query("outcome"),
+		Target:  c.Query("target"),
+	}
+	locale := requestLocale(c)
+	var fields []apperror.FieldError
+
+	if raw := c.Query("actor_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			fields = append(fields, customValidator.NewFieldError(locale, "actor_id", "numeric", ""))
+		} else {
+			actorID := uint(id)
+			filter.ActorID = &actorID
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if raw := c.Query(p.name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				fields = append(fields, customValidator.NewFieldError(locale, p.name, "datetime", time.RFC3339))
+				continue
+			}
+			*p.dst = t
+		}
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		fields = append(fields, customValidator.NewFieldError(locale, "page", "numeric", ""))
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(auditUsecase.DefaultPageSize)))
+	if err != nil {
+		fields = append(fields, customValidator.NewFieldError(locale, "page_size", "numeric", ""))
+	}
+
+	if len(fields) > 0 {
+		c.Error(apperror.Validation("invalid query parameters", fields...))
+		return
+	}
+
+	result, err := h.uc.List(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// newAuditEvent เติมข้อมูลของ request (actor, IP, user agent, request ID) ให้ audit event
+func newAuditEvent(c *gin.Context, action, outcome string) entity.AuditEvent {
+	event := entity.AuditEvent{
+		Action:    action,
+		Outcome:   outcome,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: middleware.GetRequestID(c),
+	}
+	if id, ok := c.Get("user_id"); ok {
+		if actorID, ok := id.(uint); ok {
+			event.ActorID = &actorID
+		}
+	}
+	return event
+}
+
+func userTarget(id uint) string {
+	return "user:" + strconv.FormatUint(uint64(id), 10)
+}
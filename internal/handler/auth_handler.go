@@ -1,32 +1,42 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
-	rdb "github.com/redis/go-redis/v9"
+
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/audit"
 	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/logger"
 	"github.com/ipxsandbox/internal/pkg/redis"
 	"github.com/ipxsandbox/internal/usecase/auth_usercase"
 	customValidator "github.com/ipxsandbox/internal/validator"
+	rdb "github.com/redis/go-redis/v9"
 )
 
+type AuthOptions struct {
+	// ConcealRegistration ตอบ /register เหมือนกันทุกกรณี ไม่ว่า email จะถูกใช้ไปแล้วหรือไม่
+	// เพื่อไม่ให้ใช้ /register ตรวจสอบว่า email ไหนมีบัญชีอยู่
+	ConcealRegistration bool
+}
+
 type AuthHandler struct {
 	authUsecase auth_usercase.AuthUsecaseInterface
+	audit       audit.Recorder
+	cookies     *cookieutil.Policy
+	validate    *customValidator.Registry
+	opts        AuthOptions
 }
 
-func NewAuthHandler(auc auth_usercase.AuthUsecaseInterface) *AuthHandler {
-	return &AuthHandler{authUsecase: auc}
-}
-
-var validate *validator.Validate
-
-func init() {
-	validate = validator.New()
-	customValidator.RegisterCustomValidators(validate)
+func NewAuthHandler(auc auth_usercase.AuthUsecaseInterface, recorder audit.Recorder, cookies *cookieutil.Policy, validate *customValidator.Registry, opts AuthOptions) *AuthHandler {
+	return &AuthHandler{authUsecase: auc, audit: recorder, cookies: cookies, validate: validate, opts: opts}
 }
 
 const (
@@ -35,92 +45,108 @@ const (
 )
 
 func (h *AuthHandler) Register(c *gin.Context) {
-	var userData entity.User
-	err := c.ShouldBindJSON(&userData)
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid data"})
+	var req RegisterRequest
+	if err := bindAndValidate(c, h.validate, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := validate.Struct(userData); err != nil {
-		log.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": customValidator.TranslateValidationError(err)})
+	resp, err := h.authUsecase.Register(c.Request.Context(), req.User())
+	h.auditRegister(c, req.Email, resp, err)
+	if h.opts.ConcealRegistration && (err == nil || errors.Is(err, auth_usercase.ErrEmailTaken)) {
+		c.JSON(http.StatusAccepted, gin.H{"message": "registration received"})
 		return
 	}
-
-	resp, err := h.authUsecase.Register(userData)
 	if err != nil {
-		log.Println(err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register user"})
+		c.Error(err)
 		return
 	}
 
-    c.JSON(http.StatusCreated, resp)
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *AuthHandler) isBlocked(c *gin.Context, email string) bool {
-	blockKey := fmt.Sprintf("login_blocked:%s", email)
-	blockTTL, err := redis.Rdb.TTL(redis.Ctx, blockKey).Result()
+	blockKey := fmt.Sprintf("login_blocked:%s", emailutil.Key(email))
+	blockTTL, err := redis.Rdb.TTL(c.Request.Context(), blockKey).Result()
 	if err != nil {
-		log.Println("Redis TTL error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		c.Error(fmt.Errorf("redis TTL: %w", err))
 		return true
 	}
 	if blockTTL > 0 {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": fmt.Sprintf("Too many failed attempts. Try again in %v", blockTTL.Round(time.Second)),
-		})
+		h.auditLogin(c, email, audit.OutcomeBlocked, "")
+		c.Error(apperror.RateLimited(
+			fmt.Sprintf("Too many failed attempts. Try again in %v", blockTTL.Round(time.Second)),
+			blockTTL,
+		))
 		return true
 	}
 	return false
 }
 
-func (h *AuthHandler) handleLoginSuccess(c *gin.Context, email, accessToken, refreshToken string) {
-	attemptKey := fmt.Sprintf("login_attempt:%s", email)
-	blockKey := fmt.Sprintf("login_blocked:%s", email)
+func (h *AuthHandler) handleLoginSuccess(c *gin.Context, email string, result auth_usercase.LoginResult) {
+	attemptKey := fmt.Sprintf("login_attempt:%s", emailutil.Key(email))
+	blockKey := fmt.Sprintf("login_blocked:%s", emailutil.Key(email))
 
-	if err := redis.Rdb.Del(redis.Ctx, attemptKey, blockKey).Err(); err != nil {
-		log.Println("Failed to delete Redis keys after login:", err)
+	if err := redis.Rdb.Del(c.Request.Context(), attemptKey, blockKey).Err(); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("Failed to delete Redis keys after login", slog.Any("error", err))
 	}
 
-	c.SetCookie("access_token", accessToken, 60*15, "/", "localhost", false, true)
-	c.SetCookie("refresh_token", refreshToken, 60*60*24*7, "/", "localhost", false, true)
+	detail := ""
+	if result.PasswordChangeRequired {
+		detail = "password change required"
+	}
+	h.auditLogin(c, email, audit.OutcomeSuccess, detail)
 
-	c.JSON(http.StatusOK, gin.H{"message": "login success"})
+	// ส่ง CSRF token ใน body ด้วย เพราะ SPA ที่อยู่คนละ origin อ่าน cookie แบบ host-only ของเราไม่ได้
+	csrfToken, err := h.cookies.IssueCSRFToken(c.Writer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.cookies.SetAccessToken(c.Writer, result.AccessToken)
+
+	// token ที่ใช้ได้แค่เปลี่ยนรหัสผ่านไม่มี refresh token client ต้องเรียก /change-password แล้ว login ใหม่
+	if result.PasswordChangeRequired {
+		c.JSON(http.StatusOK, gin.H{"message": "password change required", "password_change_required": true, "csrf_token": csrfToken})
+		return
+	}
+	h.cookies.SetRefreshToken(c.Writer, result.RefreshToken)
+
+	c.JSON(http.StatusOK, gin.H{"message": "login success", "password_change_required": false, "csrf_token": csrfToken})
 }
 
 func (h *AuthHandler) handleLoginFailure(c *gin.Context, email string) {
-	attemptKey := fmt.Sprintf("login_attempt:%s", email)
-	blockKey := fmt.Sprintf("login_blocked:%s", email)
+	// ตัวนับต้องเพิ่มเสมอ ไม่อย่างนั้นผู้โจมตีตัดการเชื่อมต่อหลังส่ง request เพื่อเลี่ยงการ block ได้
+	ctx := context.WithoutCancel(c.Request.Context())
+	attemptKey := fmt.Sprintf("login_attempt:%s", emailutil.Key(email))
+	blockKey := fmt.Sprintf("login_blocked:%s", emailutil.Key(email))
 
 	// ตรวจสอบว่าโดน block อยู่ไหม
-	blockTTL, err := redis.Rdb.TTL(redis.Ctx, blockKey).Result()
+	blockTTL, err := redis.Rdb.TTL(ctx, blockKey).Result()
 	if err != nil && err != rdb.Nil {
-		log.Println("Failed to check TTL of login_blocked:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		c.Error(fmt.Errorf("check TTL of login_blocked: %w", err))
 		return
 	}
 
 	// หากยังถูก block อยู่ ให้แจ้งกลับ
 	if blockTTL > 0 {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": fmt.Sprintf("Too many failed attempts. You are still blocked for %v", blockTTL.Round(time.Second)),
-		})
+		h.auditLogin(c, email, audit.OutcomeBlocked, "")
+		c.Error(apperror.RateLimited(
+			fmt.Sprintf("Too many failed attempts. You are still blocked for %v", blockTTL.Round(time.Second)),
+			blockTTL,
+		))
 		return
 	}
 
-	attempts, err := redis.Rdb.Get(redis.Ctx, attemptKey).Int()
+	attempts, err := redis.Rdb.Get(ctx, attemptKey).Int()
 	if err != nil && err != rdb.Nil {
-		log.Println("Failed to get login_attempt from Redis:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		c.Error(fmt.Errorf("get login_attempt: %w", err))
 		return
 	}
 
 	attempts++
-	if err := redis.Rdb.Set(redis.Ctx, attemptKey, attempts, 15*time.Minute).Err(); err != nil {
-		log.Println("Failed to set login_attempt in Redis:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	if err := redis.Rdb.Set(ctx, attemptKey, attempts, 15*time.Minute).Err(); err != nil {
+		c.Error(fmt.Errorf("set login_attempt: %w", err))
 		return
 	}
 
@@ -128,66 +154,138 @@ func (h *AuthHandler) handleLoginFailure(c *gin.Context, email string) {
 		blockMultiplier := attempts - maxLoginAttempts
 		blockTime := initialBlockTime * time.Duration(blockMultiplier)
 
-		if err := redis.Rdb.Set(redis.Ctx, blockKey, "blocked", blockTime).Err(); err != nil {
-			log.Println("Failed to set login_blocked in Redis:", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		if err := redis.Rdb.Set(ctx, blockKey, "blocked", blockTime).Err(); err != nil {
+			c.Error(fmt.Errorf("set login_blocked: %w", err))
 			return
 		}
 
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": fmt.Sprintf("Too many failed attempts. You are blocked for %v", blockTime),
-		})
+		h.auditLogin(c, email, audit.OutcomeBlocked, fmt.Sprintf("blocked for %v after %d attempts", blockTime, attempts))
+		c.Error(apperror.RateLimited(
+			fmt.Sprintf("Too many failed attempts. You are blocked for %v", blockTime),
+			blockTime,
+		))
 		return
 	}
 
-	c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	h.auditLogin(c, email, audit.OutcomeFailure, fmt.Sprintf("attempt %d", attempts))
+	c.Error(auth_usercase.ErrInvalidCredentials)
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
-	var userData struct {
-		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8,password"`
+	var userData LoginRequest
+	if err := bindAndValidate(c, h.validate, &userData); err != nil {
+		c.Error(err)
+		return
 	}
-	if err := c.ShouldBindJSON(&userData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid data"})
+
+	if h.isBlocked(c, userData.Email) {
 		return
 	}
 
-	if err := validate.Struct(userData); err != nil {
-		log.Println(err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": customValidator.TranslateValidationError(err)})
+	result, err := h.authUsecase.Login(c.Request.Context(), userData.Email, userData.Password)
+	if errors.Is(err, auth_usercase.ErrInvalidCredentials) {
+		h.handleLoginFailure(c, userData.Email)
+		return
+	}
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if h.isBlocked(c, userData.Email) {
+	h.handleLoginSuccess(c, userData.Email, result)
+}
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	refreshToken := h.cookies.RefreshToken(c.Request)
+	if refreshToken == "" {
+		c.Error(apperror.Unauthorized("refresh token not found"))
+		return
+	}
+
+	newAccessToken, err := h.authUsecase.RefreshAccessToken(c.Request.Context(), refreshToken)
+	if err != nil {
+		event := newAuditEvent(c, audit.ActionTokenRefresh, audit.OutcomeFailure)
+		event.Detail = apperror.From(err).Message
+		h.audit.Record(c.Request.Context(), event)
+		c.Error(err)
 		return
 	}
+	h.audit.Record(c.Request.Context(), newAuditEvent(c, audit.ActionTokenRefresh, audit.OutcomeSuccess))
 
-	accessToken, refreshToken, err := h.authUsecase.Login(userData.Email, userData.Password)
-	if err == nil {
-		h.handleLoginSuccess(c, userData.Email, accessToken, refreshToken)
+	csrfToken, err := h.cookies.RenewCSRFToken(c.Writer, c.Request)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	h.cookies.SetAccessToken(c.Writer, newAccessToken)
 
-	h.handleLoginFailure(c, userData.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "token refreshed", "csrf_token": csrfToken})
 }
 
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil || refreshToken == "" {
-		log.Println(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token not found"})
+// ChangePassword ต้องวางหลัง JWTAuthMiddleware
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := bindAndValidate(c, h.validate, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	newAccessToken, err := h.authUsecase.RefreshAccessToken(refreshToken)
+	userID := c.GetUint("user_id")
+	err := h.authUsecase.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword)
+	event := newAuditEvent(c, audit.ActionPasswordChange, audit.OutcomeSuccess)
+	event.Target = userTarget(userID)
 	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired refresh token"})
+		event.Outcome = audit.OutcomeFailure
+		event.Detail = apperror.From(err).Message
+	}
+	h.audit.Record(c.Request.Context(), event)
+	if err != nil {
+		c.Error(localizeFields(c, err))
 		return
 	}
 
-	c.SetCookie("access_token", newAccessToken, 60*15, "/", "localhost", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+func (h *AuthHandler) auditRegister(c *gin.Context, email string, resp entity.UserResponse, err error) {
+	event := newAuditEvent(c, audit.ActionRegister, audit.OutcomeSuccess)
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.Target = email
+		event.Detail = apperror.From(err).Message
+	} else {
+		event.Target = userTarget(resp.ID)
+	}
+	h.audit.Record(c.Request.Context(), event)
+}
+
+func (h *AuthHandler) auditLogin(c *gin.Context, email, outcome, detail string) {
+	event := newAuditEvent(c, audit.ActionLogin, outcome)
+	event.Target = email
+	event.Detail = detail
+	h.audit.Record(c.Request.Context(), event)
+}
+
+func validationError(c *gin.Context, err error) *apperror.Error {
+	return apperror.Validation("validation failed", customValidator.TranslateValidationError(err, requestLocale(c))...).WithCause(err)
+}
+
+// localizeFields แปลข้อความของ field error ที่ usecase ส่งมาเป็นภาษาของ request
+// usecase ไม่รู้ภาษาของ request จึงตั้ง Message เป็นภาษาอังกฤษไว้เท่านั้น
+func localizeFields(c *gin.Context, err error) error {
+	appErr := apperror.From(err)
+	if appErr.Kind != apperror.KindValidation || len(appErr.Fields) == 0 {
+		return err
+	}
+	locale := requestLocale(c)
+	fields := make([]apperror.FieldError, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		fields[i] = customValidator.NewFieldError(locale, f.Field, f.Code, f.Params["param"])
+	}
+	return appErr.WithFields(fields...)
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "token refreshed"})
+// requestLocale ใช้ภาษาในโปรไฟล์ของ user ที่ login แล้วก่อน แล้วจึงใช้ Accept-Language
+func requestLocale(c *gin.Context) string {
+	return customValidator.NegotiateLocale(c.GetString("user_locale"), c.GetHeader("Accept-Language"))
 }
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/audit"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/middleware"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	"github.com/ipxsandbox/internal/pkg/redis"
+	"github.com/ipxsandbox/internal/usecase/auth_usercase"
+	customValidator "github.com/ipxsandbox/internal/validator"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock Auth Usecase
+type mockAuthUsecase struct {
+	mock.Mock
+}
+
+func (m *mockAuthUsecase) Register(ctx context.Context, user entity.User) (entity.UserResponse, error) {
+	args := m.Called(user)
+	return args.Get(0).(entity.UserResponse), args.Error(1)
+}
+
+func (m *mockAuthUsecase) Login(ctx context.Context, email, password string) (auth_usercase.LoginResult, error) {
+	args := m.Called(email, password)
+	return args.Get(0).(auth_usercase.LoginResult), args.Error(1)
+}
+
+func (m *mockAuthUsecase) RefreshAccessToken(ctx context.Context, refreshToken string) (string, error) {
+	args := m.Called(refreshToken)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockAuthUsecase) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	args := m.Called(userID, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+var testCookies = func() *cookieutil.Policy {
+	p, err := cookieutil.New(cookieutil.Config{
+		Secure:        true,
+		HostPrefix:    true,
+		RefreshPath:   "/refresh-token",
+		AccessMaxAge:  15 * time.Minute,
+		RefreshMaxAge: time.Hour,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+func setupAuthRouter(uc auth_usercase.AuthUsecaseInterface, opts AuthOptions) *gin.Engine {
+	handler := NewAuthHandler(uc, audit.Nop(), testCookies, NewValidator(takenEmails{}, passwordpolicy.Default()), opts)
+	r := gin.Default()
+	r.Use(middleware.ErrorHandler())
+	r.POST("/register", handler.Register)
+	r.POST("/refresh-token", handler.RefreshToken)
+	r.POST("/change-password", func(c *gin.Context) { c.Set("user_id", uint(7)) }, handler.ChangePassword)
+	return r
+}
+
+func registerRequest() *http.Request {
+	body, _ := json.Marshal(RegisterRequest{Name: "Alice", Email: "alice@example.com", Password: "Secret#123"})
+	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRegisterHandler_EmailTaken(t *testing.T) {
+	mockUC := new(mockAuthUsecase)
+	mockUC.On("Register", mock.Anything).Return(entity.UserResponse{}, auth_usercase.ErrEmailTaken)
+
+	r := setupAuthRouter(mockUC, AuthOptions{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, registerRequest())
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestRegisterHandler_ConcealedResponsesMatch(t *testing.T) {
+	takenUC := new(mockAuthUsecase)
+	takenUC.On("Register", mock.Anything).Return(entity.UserResponse{}, auth_usercase.ErrEmailTaken)
+	newUC := new(mockAuthUsecase)
+	newUC.On("Register", mock.Anything).Return(entity.UserResponse{ID: 1, Name: "Alice", Email: "alice@example.com"}, nil)
+
+	taken := httptest.NewRecorder()
+	setupAuthRouter(takenUC, AuthOptions{ConcealRegistration: true}).ServeHTTP(taken, registerRequest())
+	created := httptest.NewRecorder()
+	setupAuthRouter(newUC, AuthOptions{ConcealRegistration: true}).ServeHTTP(created, registerRequest())
+
+	assert.Equal(t, http.StatusAccepted, taken.Code)
+	assert.Equal(t, taken.Code, created.Code)
+	assert.Equal(t, taken.Body.String(), created.Body.String())
+}
+
+func TestRefreshTokenHandler_UsesCookiePolicy(t *testing.T) {
+	mockUC := new(mockAuthUsecase)
+	mockUC.On("RefreshAccessToken", "refresh-value").Return("new-access", nil)
+	r := setupAuthRouter(mockUC, AuthOptions{})
+
+	req, _ := http.NewRequest("POST", "/refresh-token", nil)
+	req.AddCookie(&http.Cookie{Name: "__Secure-refresh_token", Value: "refresh-value"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	if c := cookies["__Host-access_token"]; assert.NotNil(t, c) {
+		assert.Equal(t, "new-access", c.Value)
+		assert.Equal(t, "/", c.Path)
+		assert.Equal(t, 900, c.MaxAge)
+		assert.True(t, c.Secure)
+		assert.True(t, c.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+	}
+	if c := cookies["__Host-csrf_token"]; assert.NotNil(t, c, "refresh should keep a CSRF token alive") {
+		assert.Equal(t, c.Value, csrfTokenFromBody(t, w))
+	}
+	mockUC.AssertExpectations(t)
+}
+
+// csrfTokenFromBody อ่าน CSRF token ที่ส่งใน body ให้ client ที่อ่าน cookie ของเราไม่ได้
+func csrfTokenFromBody(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotEmpty(t, body.CSRFToken)
+	return body.CSRFToken
+}
+
+func TestLoginSuccessReturnsCSRFToken(t *testing.T) {
+	// Redis ที่ต่อไม่ได้ทำให้ลบตัวนับ login ไม่สำเร็จ ซึ่ง handler แค่ log ไว้แล้วทำงานต่อ
+	prev := redis.Rdb
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() {
+		redis.Rdb.Close()
+		redis.Rdb = prev
+	})
+	h := NewAuthHandler(new(mockAuthUsecase), audit.Nop(), testCookies, NewValidator(takenEmails{}, passwordpolicy.Default()), AuthOptions{})
+
+	tests := []struct {
+		name   string
+		result auth_usercase.LoginResult
+	}{
+		{"full access", auth_usercase.LoginResult{AccessToken: "access", RefreshToken: "refresh"}},
+		{"password change required", auth_usercase.LoginResult{AccessToken: "restricted", PasswordChangeRequired: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+
+			h.handleLoginSuccess(c, "alice@example.com", tt.result)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var csrfCookie *http.Cookie
+			for _, ck := range w.Result().Cookies() {
+				if ck.Name == "__Host-csrf_token" {
+					csrfCookie = ck
+				}
+			}
+			if assert.NotNil(t, csrfCookie) {
+				assert.Equal(t, csrfCookie.Value, csrfTokenFromBody(t, w))
+			}
+		})
+	}
+}
+
+func TestRefreshTokenHandler_IgnoresUnprefixedCookie(t *testing.T) {
+	r := setupAuthRouter(new(mockAuthUsecase), AuthOptions{})
+
+	req, _ := http.NewRequest("POST", "/refresh-token", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-value"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRegisterHandler_LocalizedValidationErrors(t *testing.T) {
+	r := setupAuthRouter(new(mockAuthUsecase), AuthOptions{})
+
+	body, _ := json.Marshal(RegisterRequest{Name: "Alice", Email: "not-an-email", Password: "Secret#123"})
+	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "th-TH,th;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "email", Code: "email", Message: "อีเมลต้องเป็นอีเมลที่ถูกต้อง"},
+	}, problem.Errors)
+}
+
+func TestRequestValidationTagsAreTranslated(t *testing.T) {
+	for _, dto := range []any{RegisterRequest{}, LoginRequest{}, CreateUserRequest{}, ChangePasswordRequest{}} {
+		typ := reflect.TypeOf(dto)
+		for i := range typ.NumField() {
+			for _, rule := range strings.Split(typ.Field(i).Tag.Get("validate"), ",") {
+				tag, _, _ := strings.Cut(rule, "=")
+				if tag == "" || tag == "omitempty" {
+					continue
+				}
+				for _, locale := range customValidator.Locales() {
+					assert.True(t, customValidator.HasMessage(locale, tag), "%s: no %s message for tag %q", typ.Name(), locale, tag)
+				}
+			}
+		}
+	}
+}
+
+func TestRegisterHandler_PasswordMustDifferFromEmail(t *testing.T) {
+	r := setupAuthRouter(new(mockAuthUsecase), AuthOptions{})
+
+	body, _ := json.Marshal(RegisterRequest{Name: "Alice", Email: "Alice1@example.com", Password: "alice1@EXAMPLE.com"})
+	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "password", Code: "nefield", Params: map[string]string{"param": "email"}, Message: "Password must be different from Email"},
+	}, problem.Errors)
+}
+
+func changePasswordRequest(current, next string) *http.Request {
+	body, _ := json.Marshal(ChangePasswordRequest{CurrentPassword: current, NewPassword: next})
+	req, _ := http.NewRequest("POST", "/change-password", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	mockUC := new(mockAuthUsecase)
+	mockUC.On("ChangePassword", uint(7), "Secret#123", "Secret#456").Return(nil)
+	r := setupAuthRouter(mockUC, AuthOptions{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, changePasswordRequest("Secret#123", "Secret#456"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestChangePasswordHandler_NewPasswordMustDiffer(t *testing.T) {
+	mockUC := new(mockAuthUsecase)
+	r := setupAuthRouter(mockUC, AuthOptions{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, changePasswordRequest("Secret#123", "Secret#123"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "new_password", problem.Errors[0].Field)
+		assert.Equal(t, "nefield", problem.Errors[0].Code)
+	}
+	mockUC.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestChangePasswordHandler_ReusedPasswordIsLocalized(t *testing.T) {
+	mockUC := new(mockAuthUsecase)
+	mockUC.On("ChangePassword", uint(7), "Secret#123", "Secret#000").Return(auth_usercase.ErrPasswordReused)
+	r := setupAuthRouter(mockUC, AuthOptions{})
+
+	req := changePasswordRequest("Secret#123", "Secret#000")
+	req.Header.Set("Accept-Language", "th")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "new_password", Code: "password_reused", Message: "รหัสผ่านใหม่ต้องไม่ซ้ำกับรหัสผ่านที่เคยใช้เมื่อเร็วๆ นี้"},
+	}, problem.Errors)
+}
+
+func TestChangePasswordRequest_NewPasswordMustDiffer(t *testing.T) {
+	v := NewValidator(takenEmails{}, passwordpolicy.Default())
+
+	assert.NoError(t, v.Struct(context.Background(), ChangePasswordRequest{CurrentPassword: "Secret#123", NewPassword: "Secret#456"}))
+
+	err := v.Struct(context.Background(), ChangePasswordRequest{CurrentPassword: "Secret#123", NewPassword: "Secret#123"})
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "new_password", Code: "nefield", Params: map[string]string{"param": "current_password"}, Message: "New password must be different from Current password"},
+	}, customValidator.TranslateValidationError(err, customValidator.DefaultLocale))
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/middleware"
+	customValidator "github.com/ipxsandbox/internal/validator"
+)
+
+var (
+	ErrEmptyBody    = apperror.Validation("request body is required")
+	ErrInvalidJSON  = apperror.Validation("request body is not valid JSON")
+	ErrTrailingData = apperror.Validation("request body must contain a single JSON object")
+)
+
+// bindAndValidate ถอด body เป็น dst แล้วตรวจด้วย rule ของ v
+// error ที่คืนเป็น validation error ที่แปลตามภาษาของ request แล้ว
+func bindAndValidate(c *gin.Context, v *customValidator.Registry, dst any) error {
+	if err := bindJSON(c, dst); err != nil {
+		return err
+	}
+	if err := v.Struct(c.Request.Context(), dst); err != nil {
+		return validationError(c, err)
+	}
+	return nil
+}
+
+// bindJSON ถอด body เป็น dst แบบเข้มงวด ไม่รับ field ที่ไม่รู้จักและข้อมูลที่ต่อท้าย object
+// แทน ShouldBindJSON ที่ยอมรับทั้งสองอย่างโดยไม่แจ้ง
+func bindJSON(c *gin.Context, dst any) error {
+	if c.Request.Body == nil {
+		return ErrEmptyBody
+	}
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err, requestLocale(c))
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return middleware.ErrBodyTooLarge.WithCause(err)
+		}
+		return ErrTrailingData.WithCause(err)
+	}
+	return nil
+}
+
+// decodeError แปลง error ของ encoding/json เป็น validation error ที่บอกว่า field ไหนถอดไม่ได้
+func decodeError(err error, locale string) error {
+	var (
+		maxErr    *http.MaxBytesError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &maxErr):
+		return middleware.ErrBodyTooLarge.WithCause(err)
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			return ErrInvalidJSON.WithCause(err)
+		}
+		fe := customValidator.NewFieldError(locale, field, "type", jsonType(typeErr.Type))
+		return apperror.Validation("invalid data", fe).WithCause(err)
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrInvalidJSON.WithCause(err)
+	}
+	// encoding/json ไม่มี error type ของ field ที่ไม่รู้จัก
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		fe := customValidator.NewFieldError(locale, strings.Trim(name, `"`), "unknown_field", "")
+		return apperror.Validation("invalid data", fe).WithCause(err)
+	}
+	return ErrInvalidJSON.WithCause(err)
+}
+
+// jsonType คือชื่อชนิดข้อมูลของ JSON ที่ field ต้องการ แทนชื่อ type ของ Go ที่ client ไม่รู้จัก
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	}
+	return "object"
+}
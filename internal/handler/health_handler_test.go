@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	registry := health.NewRegistry()
+	redisErr := errors.New("dial tcp: connection refused")
+	registry.Register("postgres", time.Second, func(context.Context) error { return nil })
+	registry.Register("redis", time.Second, func(context.Context) error { return redisErr })
+
+	handler := NewHealthHandler(registry)
+	r := gin.Default()
+	r.GET("/healthz", handler.Liveness)
+	r.GET("/readyz", handler.Readiness)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "liveness does not depend on redis")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusUp, report.Checks["postgres"].Status)
+	assert.Equal(t, health.StatusDown, report.Checks["redis"].Status)
+	assert.NotContains(t, w.Body.String(), "connection refused", "dependency errors stay in server logs")
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+)
+
+type PasswordPolicyHandler struct {
+	policy passwordpolicy.Policy
+}
+
+func NewPasswordPolicyHandler(policy passwordpolicy.Policy) *PasswordPolicyHandler {
+	return &PasswordPolicyHandler{policy: policy}
+}
+
+// Get ให้ frontend แสดงเงื่อนไขของรหัสผ่านก่อนส่งฟอร์มสมัครหรือเปลี่ยนรหัสผ่าน
+// policy เปลี่ยนเฉพาะตอน deploy จึงให้ cache ได้
+func (h *PasswordPolicyHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.policy)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicyHandler(t *testing.T) {
+	policy := passwordpolicy.Default()
+	policy.MaxRepeated = 3
+
+	r := gin.New()
+	r.GET("/password-policy", NewPasswordPolicyHandler(policy).Get)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/password-policy", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got passwordpolicy.Policy
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, policy, got)
+	assert.JSONEq(t, `{
+		"min_length": 8, "max_length": 72, "max_bytes": 0,
+		"require_upper": true, "require_lower": true, "require_digit": true, "require_special": true,
+		"max_repeated": 3, "history_depth": 0
+	}`, w.Body.String())
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	customValidator "github.com/ipxsandbox/internal/validator"
+)
+
+// request DTO แยกจาก entity เพื่อไม่ให้ client กำหนด field ของฐานข้อมูลเองได้ เช่น ID หรือ Role
+
+// RegisterRequest ไม่ใช้ unique_email เพราะจะบอกได้ว่า email ไหนมีบัญชีอยู่ แม้เปิด ConcealRegistration
+// email ซ้ำจึงให้ usecase ตอบแทน
+type RegisterRequest struct {
+	Name     string `json:"name" validate:"required,max=20"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,password"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,locale"`
+}
+
+func (r RegisterRequest) User() entity.User {
+	return entity.User{Name: r.Name, Email: r.Email, Password: r.Password, Locale: r.Locale}
+}
+
+// LoginRequest ไม่ตรวจ password policy เพื่อให้รหัสผ่านที่ตั้งไว้ก่อน policy เปลี่ยนยัง login ได้
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type CreateUserRequest struct {
+	Name     string `json:"name" validate:"required,max=20"`
+	Email    string `json:"email" validate:"required,email,unique_email"`
+	Password string `json:"password" validate:"required,password"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,locale"`
+}
+
+func (r CreateUserRequest) User() entity.User {
+	return entity.User{Name: r.Name, Email: r.Email, Password: r.Password, Locale: r.Locale}
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
+}
+
+// NewValidator สร้าง validator ของ handler พร้อม rule ระดับ struct ของ request DTO ทุกตัว
+// tag password ของ DTO ตรวจตาม policy
+func NewValidator(users customValidator.EmailLookup, policy passwordpolicy.Policy) *customValidator.Registry {
+	v := customValidator.NewRegistry(customValidator.Dependencies{Users: users, Password: policy})
+	v.RegisterStruct(passwordNotEmail, RegisterRequest{}, CreateUserRequest{})
+	v.RegisterStruct(newPasswordDiffers, ChangePasswordRequest{})
+	return v
+}
+
+func passwordNotEmail(_ context.Context, sl validator.StructLevel) {
+	var email, password string
+	switch r := sl.Current().Interface().(type) {
+	case RegisterRequest:
+		email, password = r.Email, r.Password
+	case CreateUserRequest:
+		email, password = r.Email, r.Password
+	}
+	customValidator.MustDiffer(sl, "password", password, "email", email, strings.EqualFold)
+}
+
+func newPasswordDiffers(_ context.Context, sl validator.StructLevel) {
+	r := sl.Current().Interface().(ChangePasswordRequest)
+	customValidator.MustDiffer(sl, "new_password", r.NewPassword, "current_password", r.CurrentPassword, func(a, b string) bool { return a == b })
+}
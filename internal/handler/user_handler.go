@@ -1,39 +1,81 @@
 package handler
 
 import (
-    "net/http"
-    "github.com/gin-gonic/gin"
-    "github.com/ipxsandbox/internal/entity"
-    usecaseUser "github.com/ipxsandbox/internal/usecase/user"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/audit"
+	"github.com/ipxsandbox/internal/entity"
+	usecaseUser "github.com/ipxsandbox/internal/usecase/user"
+	customValidator "github.com/ipxsandbox/internal/validator"
 )
 
 type UserHandler struct {
-    uc usecaseUser.Usecase
+	uc       usecaseUser.Usecase
+	audit    audit.Recorder
+	validate *customValidator.Registry
 }
 
-func NewUserHandler(uc usecaseUser.Usecase) *UserHandler {
-    return &UserHandler{uc: uc}
+func NewUserHandler(uc usecaseUser.Usecase, recorder audit.Recorder, validate *customValidator.Registry) *UserHandler {
+	return &UserHandler{uc: uc, audit: recorder, validate: validate}
 }
 
 func (h *UserHandler) GetUsers(c *gin.Context) {
-    users, err := h.uc.GetAllUsers()
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, users)
+	users, err := h.uc.GetAllUsers(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.audit.Record(c.Request.Context(), newAuditEvent(c, audit.ActionUserList, audit.OutcomeSuccess))
+	resp := make([]entity.UserResponse, len(users))
+	for i, u := range users {
+		resp[i] = u.Response()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *UserHandler) CreateUser(c *gin.Context) {
-    var user entity.User
-    if err := c.ShouldBindJSON(&user); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    created, err := h.uc.CreateUser(user)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusCreated, created)
-}
\ No newline at end of file
+	var req CreateUserRequest
+	if err := bindAndValidate(c, h.validate, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	created, err := h.uc.CreateUser(c.Request.Context(), req.User())
+	if err != nil {
+		event := newAuditEvent(c, audit.ActionUserCreate, audit.OutcomeFailure)
+		event.Target = req.Email
+		event.Detail = apperror.From(err).Message
+		h.audit.Record(c.Request.Context(), event)
+		c.Error(err)
+		return
+	}
+	event := newAuditEvent(c, audit.ActionUserCreate, audit.OutcomeSuccess)
+	event.Target = userTarget(created.ID)
+	h.audit.Record(c.Request.Context(), event)
+	c.JSON(http.StatusCreated, created.Response())
+}
+
+// RequirePasswordChange POST /admin/users/:id/require-password-change
+// ให้ user ต้องเปลี่ยนรหัสผ่านก่อนใช้งานอื่น session ที่มีอยู่ใช้ได้จนกว่า access token จะหมดอายุ
+func (h *UserHandler) RequirePasswordChange(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.Validation("invalid path parameters", customValidator.NewFieldError(requestLocale(c), "id", "numeric", "")))
+		return
+	}
+	err = h.uc.RequirePasswordChange(c.Request.Context(), uint(id))
+	event := newAuditEvent(c, audit.ActionUserRequirePasswordChange, audit.OutcomeSuccess)
+	event.Target = userTarget(uint(id))
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.Detail = apperror.From(err).Message
+	}
+	h.audit.Record(c.Request.Context(), event)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password change required"})
+}
@@ -2,14 +2,23 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/audit"
 	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/middleware"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	userRepository "github.com/ipxsandbox/internal/repository/user"
 	userUsecase "github.com/ipxsandbox/internal/usecase/user"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,27 +29,60 @@ type mockUserUsecase struct {
 	mock.Mock
 }
 
-func (m *mockUserUsecase) GetAllUsers() ([]entity.User, error) {
+func (m *mockUserUsecase) GetAllUsers(ctx context.Context) ([]entity.User, error) {
 	args := m.Called()
 	return args.Get(0).([]entity.User), args.Error(1)
 }
 
-func (m *mockUserUsecase) CreateUser(user entity.User) (entity.User, error) {
+func (m *mockUserUsecase) CreateUser(ctx context.Context, user entity.User) (entity.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(entity.User), args.Error(1)
 }
 
+func (m *mockUserUsecase) RequirePasswordChange(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// Fake audit recorder
+type recordedEvents struct {
+	events []entity.AuditEvent
+}
+
+func (r *recordedEvents) Record(ctx context.Context, event entity.AuditEvent) {
+	r.events = append(r.events, event)
+}
+
+// Fake email lookup ที่มี email ใน map ถือว่าถูกใช้แล้ว
+type takenEmails map[string]bool
+
+func (t takenEmails) FindByEmail(ctx context.Context, email string) (entity.User, error) {
+	if t[email] {
+		return entity.User{ID: 1, Email: email}, nil
+	}
+	return entity.User{}, userRepository.ErrNotFound
+}
+
 func setupRouter(uc userUsecase.Usecase) *gin.Engine {
-	handler := NewUserHandler(uc)
+	return setupRouterWithAudit(uc, audit.Nop())
+}
+
+func setupRouterWithAudit(uc userUsecase.Usecase, recorder audit.Recorder) *gin.Engine {
+	handler := NewUserHandler(uc, recorder, NewValidator(takenEmails{"taken@example.com": true}, passwordpolicy.Default()))
 	r := gin.Default()
+	r.Use(middleware.ErrorHandler())
 	r.GET("/users", handler.GetUsers)
 	r.POST("/users", handler.CreateUser)
+	r.POST("/admin/users/:id/require-password-change", handler.RequirePasswordChange)
 	return r
 }
 
 func TestGetUsersHandler_Success(t *testing.T) {
 	mockUC := new(mockUserUsecase)
-	mockUsers := []entity.User{{ID: 1, Name: "Alice", Email: "alice@example.com"}}
+	mockUsers := []entity.User{{
+		ID: 1, Name: "Alice", Email: "alice@example.com", Password: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA",
+		Role: entity.RoleAdmin, PasswordChangedAt: time.Now(), MustChangePassword: true,
+	}}
 	mockUC.On("GetAllUsers").Return(mockUsers, nil)
 
 	r := setupRouter(mockUC)
@@ -50,10 +92,7 @@ func TestGetUsersHandler_Success(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	var users []entity.User
-	err := json.Unmarshal(w.Body.Bytes(), &users)
-	assert.NoError(t, err)
-	assert.Equal(t, mockUsers, users)
+	assert.JSONEq(t, `[{"id":1,"name":"Alice","email":"alice@example.com","role":"admin","locale":""}]`, w.Body.String())
 
 	mockUC.AssertExpectations(t)
 }
@@ -74,14 +113,14 @@ func TestGetUsersHandler_Error(t *testing.T) {
 
 func TestCreateUserHandler_Success(t *testing.T) {
 	mockUC := new(mockUserUsecase)
-	inputUser := entity.User{Name: "Bob", Email: "bob@example.com"}
-	returnUser := entity.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
+	inputUser := entity.User{Name: "Bob", Email: "bob@example.com", Password: "Secret#123"}
+	returnUser := entity.User{ID: 2, Name: "Bob", Email: "bob@example.com", Password: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA", Role: entity.RoleUser}
 
 	mockUC.On("CreateUser", inputUser).Return(returnUser, nil)
 
 	r := setupRouter(mockUC)
 
-	body, _ := json.Marshal(inputUser)
+	body, _ := json.Marshal(CreateUserRequest{Name: inputUser.Name, Email: inputUser.Email, Password: inputUser.Password})
 	req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -89,10 +128,11 @@ func TestCreateUserHandler_Success(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusCreated, w.Code)
-	var createdUser entity.User
+	var createdUser entity.UserResponse
 	err := json.Unmarshal(w.Body.Bytes(), &createdUser)
 	assert.NoError(t, err)
-	assert.Equal(t, returnUser, createdUser)
+	assert.Equal(t, returnUser.Response(), createdUser)
+	assert.NotContains(t, w.Body.String(), "password")
 
 	mockUC.AssertExpectations(t)
 }
@@ -109,4 +149,172 @@ func TestCreateUserHandler_BadRequest(t *testing.T) {
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-}
\ No newline at end of file
+}
+
+func TestCreateUserHandler_ConflictRendersProblem(t *testing.T) {
+	mockUC := new(mockUserUsecase)
+	inputUser := entity.User{Name: "Bob", Email: "bob@example.com", Password: "Secret#123"}
+	dbErr := errors.New(`ERROR: duplicate key value violates unique constraint "uni_users_email" (SQLSTATE 23505)`)
+	mockUC.On("CreateUser", inputUser).Return(entity.User{}, userRepository.ErrDuplicateEmail.WithCause(dbErr))
+
+	r := setupRouter(mockUC)
+
+	body, _ := json.Marshal(CreateUserRequest{Name: inputUser.Name, Email: inputUser.Email, Password: inputUser.Password})
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, apperror.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), "SQLSTATE")
+
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, apperror.KindConflict, problem.Code)
+	assert.Equal(t, "/users", problem.Instance)
+}
+
+func TestCreateUserHandler_RecordsAuditEvent(t *testing.T) {
+	mockUC := new(mockUserUsecase)
+	inputUser := entity.User{Name: "Bob", Email: "bob@example.com", Password: "Secret#123"}
+	mockUC.On("CreateUser", inputUser).Return(entity.User{ID: 7, Name: "Bob", Email: "bob@example.com"}, nil)
+
+	recorder := &recordedEvents{}
+	r := setupRouterWithAudit(mockUC, recorder)
+
+	body, _ := json.Marshal(CreateUserRequest{Name: inputUser.Name, Email: inputUser.Email, Password: inputUser.Password})
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-agent")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, recorder.events, 1) {
+		event := recorder.events[0]
+		assert.Equal(t, audit.ActionUserCreate, event.Action)
+		assert.Equal(t, audit.OutcomeSuccess, event.Outcome)
+		assert.Equal(t, "user:7", event.Target)
+		assert.Equal(t, "test-agent", event.UserAgent)
+	}
+}
+
+func TestCreateUserHandler_RejectsUnknownFields(t *testing.T) {
+	r := setupRouter(new(mockUserUsecase))
+
+	body := `{"id":1,"name":"Bob","email":"bob@example.com","password":"Secret#123"}`
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "id", Code: "unknown_field", Message: "ID is not an allowed field"},
+	}, problem.Errors)
+}
+
+func TestCreateUserHandler_ReportsFieldWithWrongType(t *testing.T) {
+	r := setupRouter(new(mockUserUsecase))
+
+	body := `{"name":"Bob","email":42,"password":"Secret#123"}`
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "email", Code: "type", Params: map[string]string{"param": "string"}, Message: "Email must be a JSON string"},
+	}, problem.Errors)
+}
+
+func TestCreateUserHandler_RejectsTrailingData(t *testing.T) {
+	r := setupRouter(new(mockUserUsecase))
+
+	body := `{"name":"Bob","email":"bob@example.com","password":"Secret#123"}{"name":"Eve"}`
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateUserHandler_BodyTooLarge(t *testing.T) {
+	handler := NewUserHandler(new(mockUserUsecase), audit.Nop(), NewValidator(takenEmails{}, passwordpolicy.Default()))
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(), middleware.BodyLimit(1<<20, map[string]int64{"POST /users": 32}))
+	r.POST("/users", handler.CreateUser)
+
+	// ไม่ระบุ Content-Length เพื่อให้ถูกตัดตอนอ่าน body แทนการตรวจล่วงหน้า
+	body := `{"name":"Bob","email":"bob@example.com","password":"Secret#123"}`
+	req, _ := http.NewRequest("POST", "/users", io.NopCloser(strings.NewReader(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestCreateUserHandler_EmailAlreadyRegistered(t *testing.T) {
+	mockUC := new(mockUserUsecase)
+	r := setupRouter(mockUC)
+
+	body, _ := json.Marshal(CreateUserRequest{Name: "Bob", Email: "taken@example.com", Password: "Secret#123"})
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem apperror.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "email", Code: "unique_email", Message: "Email is already registered"},
+	}, problem.Errors)
+	mockUC.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestRequirePasswordChangeHandler(t *testing.T) {
+	mockUC := new(mockUserUsecase)
+	mockUC.On("RequirePasswordChange", uint(9)).Return(nil)
+	mockUC.On("RequirePasswordChange", uint(404)).Return(userRepository.ErrNotFound)
+	recorder := &recordedEvents{}
+	r := setupRouterWithAudit(mockUC, recorder)
+
+	tests := []struct {
+		id      string
+		want    int
+		outcome string
+	}{
+		{"9", http.StatusOK, audit.OutcomeSuccess},
+		{"404", http.StatusNotFound, audit.OutcomeFailure},
+		{"abc", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		recorder.events = nil
+		req, _ := http.NewRequest("POST", "/admin/users/"+tt.id+"/require-password-change", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.want, w.Code, tt.id)
+		if tt.outcome == "" {
+			assert.Empty(t, recorder.events)
+			continue
+		}
+		if assert.Len(t, recorder.events, 1) {
+			assert.Equal(t, audit.ActionUserRequirePasswordChange, recorder.events[0].Action)
+			assert.Equal(t, tt.outcome, recorder.events[0].Outcome)
+			assert.Equal(t, "user:"+tt.id, recorder.events[0].Target)
+		}
+	}
+	mockUC.AssertExpectations(t)
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+)
+
+var ErrBodyTooLarge = apperror.TooLarge("request body is too large")
+
+// BodyLimit จำกัดขนาด body ของ request ตาม route ในรูป "METHOD /path" และใช้ defaultLimit กับ route อื่น
+// ค่า 0 หรือน้อยกว่าคือไม่จำกัด body ที่ยาวเกินจะทำให้การอ่านล้มเหลวด้วย *http.MaxBytesError
+func BodyLimit(defaultLimit int64, routes map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			limit = defaultLimit
+		}
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			abortWithError(c, ErrBodyTooLarge)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	r := gin.New()
+	r.Use(ErrorHandler(), BodyLimit(8, map[string]int64{"POST /upload": 0}))
+	echo := func(c *gin.Context) {
+		b, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(ErrBodyTooLarge.WithCause(err))
+			return
+		}
+		c.String(http.StatusOK, string(b))
+	}
+	r.POST("/small", echo)
+	r.POST("/upload", echo)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"within limit", "/small", "12345678", http.StatusOK},
+		{"over default limit", "/small", "123456789", http.StatusRequestEntityTooLarge},
+		{"unlimited route", "/upload", strings.Repeat("x", 64), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
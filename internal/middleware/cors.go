@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+type CORSConfig struct {
+	// AllowOrigins เป็น origin เต็ม เช่น https://app.example.com หรือ pattern ของ subdomain
+	// เช่น https://*.example.com ซึ่งตรงกับ subdomain ทุกระดับแต่ไม่ตรงกับ example.com เอง
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+var ErrInvalidOrigin = errors.New("invalid CORS origin")
+
+// originPattern คือ origin ที่แยกส่วนไว้แล้ว ถ้า wildcard เป็น true host คือ suffix ที่ต้องตามหลัง "."
+type originPattern struct {
+	scheme   string
+	host     string
+	port     string
+	wildcard bool
+}
+
+func parseOriginPattern(raw string) (originPattern, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+		return originPattern{}, fmt.Errorf("%w %q: must look like https://app.example.com or https://*.example.com", ErrInvalidOrigin, raw)
+	}
+	p := originPattern{scheme: strings.ToLower(u.Scheme), host: strings.ToLower(u.Hostname()), port: u.Port()}
+	if p.scheme != "http" && p.scheme != "https" {
+		return originPattern{}, fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidOrigin, raw)
+	}
+	if rest, ok := strings.CutPrefix(p.host, "*."); ok {
+		p.host, p.wildcard = rest, true
+	}
+	if p.host == "" || strings.Contains(p.host, "*") || (p.wildcard && !strings.Contains(p.host, ".")) {
+		return originPattern{}, fmt.Errorf("%w %q: * is only allowed as the first label of a domain with at least two labels", ErrInvalidOrigin, raw)
+	}
+	return p, nil
+}
+
+func (p originPattern) matches(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != p.scheme || u.Port() != p.port {
+		return false
+	}
+	host := u.Hostname()
+	if !p.wildcard {
+		return host == p.host
+	}
+	sub, ok := strings.CutSuffix(host, "."+p.host)
+	return ok && sub != "" && !strings.ContainsAny(sub, "/@:*")
+}
+
+func (c CORSConfig) Validate() error {
+	if len(c.AllowOrigins) == 0 {
+		return errors.New("at least one CORS origin is required")
+	}
+	var errs []error
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			// browser ไม่ยอมให้ใช้ * กับ credential และการอนุญาตทุก origin เท่ากับปิด CORS
+			errs = append(errs, fmt.Errorf("%w: * is not allowed, list the frontend origins instead", ErrInvalidOrigin))
+			continue
+		}
+		if _, err := parseOriginPattern(origin); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("CORS max age must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// CORS ตอบ preflight และใส่ header ของ CORS ให้ origin ที่ตรงกับ AllowOrigins
+// ต้องเรียก Validate ก่อน origin ที่ผิดรูปแบบจะถูกข้ามไป
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	var patterns []originPattern
+	for _, origin := range cfg.AllowOrigins {
+		if p, err := parseOriginPattern(origin); err == nil {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return cors.New(cors.Config{
+		AllowOriginFunc: func(origin string) bool {
+			for _, p := range patterns {
+				if p.matches(origin) {
+					return true
+				}
+			}
+			return false
+		},
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSOrigins(t *testing.T) {
+	cfg := CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.staging.example.com", "http://localhost:3000"},
+		AllowMethods:     []string{"GET", "PATCH"},
+		AllowHeaders:     []string{"Content-Type", CSRFHeader},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+	require.NoError(t, cfg.Validate())
+
+	r := gin.New()
+	r.Use(CORS(cfg))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		origin string
+		allow  bool
+	}{
+		{"https://app.example.com", true},
+		{"https://pr-12.staging.example.com", true},
+		{"https://a.b.staging.example.com", true},
+		{"http://localhost:3000", true},
+		{"https://staging.example.com", false},
+		{"https://evilstaging.example.com", false},
+		{"https://pr-12.staging.example.com.evil.com", false},
+		{"http://app.example.com", false},
+		{"http://localhost:3001", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", "PATCH")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if !tt.allow {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+				return
+			}
+			assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+			assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+		})
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	for _, origin := range []string{"*", "app.example.com", "https://*", "https://*.com", "https://a.*.example.com", "ftp://example.com", "https://example.com/path"} {
+		err := CORSConfig{AllowOrigins: []string{origin}}.Validate()
+		assert.ErrorIs(t, err, ErrInvalidOrigin, origin)
+	}
+	assert.Error(t, CORSConfig{}.Validate())
+}
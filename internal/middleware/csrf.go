@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+)
+
+const CSRFHeader = "X-CSRF-Token"
+
+var ErrCSRFTokenInvalid = apperror.Forbidden("missing or invalid CSRF token")
+
+// CSRF ตรวจ double-submit token ของ request ที่เปลี่ยนข้อมูล โดยค่าใน header X-CSRF-Token
+// ต้องตรงกับ cookie ที่ออกให้ตอน login เว็บอื่นอ่าน cookie ของเราไม่ได้จึงส่ง header ที่ถูกต้องไม่ได้
+//
+// วางหลัง JWTAuthMiddleware ใน route ที่ต้อง login เพื่อข้ามการตรวจเฉพาะ request ที่ยืนยันตัวตน
+// ด้วย Authorization: Bearer จริง ๆ ส่วน route ที่ใช้ cookie อย่าง /refresh-token ถูกตรวจเสมอ
+// แม้จะมี header Authorization มาด้วย
+func CSRF(cookies *cookieutil.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+		if c.GetString(authSchemeKey) == authSchemeBearer {
+			c.Next()
+			return
+		}
+
+		cookie := cookies.CSRFToken(c.Request)
+		header := c.GetHeader(CSRFHeader)
+		if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			abortWithError(c, ErrCSRFTokenInvalid)
+			return
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
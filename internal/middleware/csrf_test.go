@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+	"github.com/ipxsandbox/internal/pkg/jwtutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCookies(t *testing.T) *cookieutil.Policy {
+	p, err := cookieutil.New(cookieutil.Config{Secure: true, AccessMaxAge: time.Minute, RefreshMaxAge: time.Hour})
+	require.NoError(t, err)
+	return p
+}
+
+func setupCSRFRouter(t *testing.T, jwtManager *jwtutil.Manager) *gin.Engine {
+	cookies := newTestCookies(t)
+	csrf := CSRF(cookies)
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.POST("/refresh-token", csrf, ok)
+	auth := r.Group("/", JWTAuthMiddleware(jwtManager, cookies), csrf)
+	auth.GET("/users", ok)
+	auth.POST("/users", ok)
+	return r
+}
+
+func TestCSRF(t *testing.T) {
+	jwtManager := jwtutil.New("test-secret-0123456789abcdefghijklmnop", time.Minute, time.Hour)
+	access, _, err := jwtManager.GenerateTokens(7, "user", "")
+	require.NoError(t, err)
+	r := setupCSRFRouter(t, jwtManager)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie string
+		bearer bool
+		header map[string]string
+		want   int
+	}{
+		{name: "safe method", method: http.MethodGet, path: "/users", want: http.StatusNoContent},
+		{name: "missing token", method: http.MethodPost, path: "/users", want: http.StatusForbidden},
+		{name: "header without cookie", method: http.MethodPost, path: "/users", header: map[string]string{CSRFHeader: "abc"}, want: http.StatusForbidden},
+		{name: "mismatch", method: http.MethodPost, path: "/users", cookie: "abc", header: map[string]string{CSRFHeader: "xyz"}, want: http.StatusForbidden},
+		{name: "match", method: http.MethodPost, path: "/users", cookie: "abc", header: map[string]string{CSRFHeader: "abc"}, want: http.StatusNoContent},
+		{name: "bearer", method: http.MethodPost, path: "/users", bearer: true, want: http.StatusNoContent},
+		{name: "bearer does not authenticate refresh", method: http.MethodPost, path: "/refresh-token", bearer: true, want: http.StatusForbidden},
+		{name: "api key is not exempt", method: http.MethodPost, path: "/users", header: map[string]string{"X-API-Key": "key"}, want: http.StatusForbidden},
+		{name: "basic auth is not exempt", method: http.MethodPost, path: "/users", header: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer "+access)
+			} else {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: access})
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestJWTAuthMiddlewareAcceptsBearer(t *testing.T) {
+	jwtManager := jwtutil.New("test-secret-0123456789abcdefghijklmnop", time.Minute, time.Hour)
+	access, _, err := jwtManager.GenerateTokens(7, "admin", "")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(ErrorHandler(), JWTAuthMiddleware(jwtManager, newTestCookies(t)))
+	r.GET("/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.GetUint("user_id"), "role": c.GetString("user_role")})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":7,"role":"admin"}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer not-a-jwt")
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: access})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "an invalid bearer token must not fall back to the cookie")
+}
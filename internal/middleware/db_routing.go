@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+)
+
+// ReadYourWrites ทำให้การอ่านหลังจากเขียนใน request เดียวกันไปที่ primary
+// เพื่อไม่ให้เห็นข้อมูลเก่าจาก read replica ที่ยังตามไม่ทัน
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(dbrouting.Track(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/pkg/logger"
+)
+
+// statusClientClosedRequest ใช้บันทึกใน access log เมื่อ client ตัดการเชื่อมต่อก่อนได้คำตอบ
+// (ตามแบบของ nginx) ไม่มี client รอรับจริง
+const statusClientClosedRequest = 499
+
+// ErrorHandler แปลง error ที่ handler ส่งผ่าน c.Error เป็น application/problem+json
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		log := logger.FromContext(c.Request.Context())
+		if errors.Is(err, context.Canceled) && c.Request.Context().Err() != nil {
+			log.Info("client canceled request", slog.Any("error", err))
+			c.AbortWithStatus(statusClientClosedRequest)
+			return
+		}
+
+		appErr := apperror.From(err)
+		switch appErr.Kind {
+		case apperror.KindInternal:
+			log.Error("request failed", slog.Any("error", err))
+		case apperror.KindTimeout:
+			log.Warn("request timed out", slog.Any("error", err))
+		}
+
+		renderProblem(c, appErr)
+	}
+}
+
+// Recovery จับ panic แล้วตอบเป็น problem+json แทน stack trace แบบ plain text ของ gin
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.FromContext(c.Request.Context()).Error("panic recovered",
+					slog.Any("panic", rec),
+					slog.String("stack", string(debug.Stack())),
+				)
+				if !c.Writer.Written() {
+					renderProblem(c, apperror.Internal(fmt.Errorf("panic: %v", rec)))
+				}
+				c.Abort()
+			}
+		}()
+		c.Next()
+	}
+}
+
+func renderProblem(c *gin.Context, appErr *apperror.Error) {
+	if appErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+
+	problem := appErr.Problem(c.Request.URL.Path, GetRequestID(c))
+	c.Header("Content-Type", apperror.ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
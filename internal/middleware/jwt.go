@@ -1,40 +1,103 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
 	"github.com/ipxsandbox/internal/pkg/jwtutil"
 )
 
-func JWTAuthMiddleware() gin.HandlerFunc {
+var (
+	ErrPasswordChangeRequired = apperror.Forbidden("password change required")
+	ErrInvalidTokenType       = apperror.Unauthorized("Invalid token type")
+)
+
+// authSchemeKey เก็บว่า request ยืนยันตัวตนด้วย Bearer หรือ cookie ให้ CSRF ใช้ตัดสินว่าต้องตรวจหรือไม่
+const (
+	authSchemeKey    = "auth_scheme"
+	authSchemeBearer = "bearer"
+	authSchemeCookie = "cookie"
+)
+
+// JWTAuthMiddleware อ่าน access token จาก Authorization: Bearer ก่อน แล้วจึงใช้ cookie
+// client ที่ไม่ใช่ browser จึงเรียก API ได้โดยไม่ต้องจัดการ cookie และ CSRF token
+//
+// token ที่ออกให้ user ที่ต้องเปลี่ยนรหัสผ่านใช้ได้เฉพาะ route ใน passwordChange
+// ในรูป "METHOD /path" เช่น "POST /change-password" route อื่นจะตอบ ErrPasswordChangeRequired
+func JWTAuthMiddleware(jwtManager *jwtutil.Manager, cookies *cookieutil.Policy, passwordChange ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(passwordChange))
+	for _, route := range passwordChange {
+		allowed[route] = struct{}{}
+	}
+
 	return func(c *gin.Context) {
-		tokenStr, err := c.Cookie("access_token")
-		if err != nil || tokenStr == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing access token"})
+		scheme, tokenStr := authSchemeBearer, bearerToken(c.Request)
+		if tokenStr == "" {
+			scheme, tokenStr = authSchemeCookie, cookies.AccessToken(c.Request)
+		}
+		if tokenStr == "" {
+			abortWithError(c, apperror.Unauthorized("Missing access token"))
 			return
 		}
 
-		token, err := jwtutil.ParseToken(tokenStr)
+		token, err := jwtManager.ParseToken(tokenStr)
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			abortWithError(c, apperror.Unauthorized("Invalid token").WithCause(err))
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			abortWithError(c, apperror.Unauthorized("Invalid token claims"))
 			return
 		}
 
 		userIDFloat, ok := claims["sub"].(float64)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+			abortWithError(c, apperror.Unauthorized("Invalid user ID in token"))
 			return
 		}
 
+		// refresh token และ token ที่ไม่มี typ ใช้ยืนยันตัวตนไม่ได้ แม้ลายเซ็นจะถูกต้อง
+		switch claims[jwtutil.ClaimType] {
+		case jwtutil.TypeAccess:
+		case jwtutil.TypePasswordChange:
+			if _, allow := allowed[c.Request.Method+" "+c.FullPath()]; !allow {
+				abortWithError(c, ErrPasswordChangeRequired)
+				return
+			}
+		default:
+			abortWithError(c, ErrInvalidTokenType)
+			return
+		}
+
+		role, _ := claims["role"].(string)
+		locale, _ := claims["locale"].(string)
+
 		c.Set("user_id", uint(userIDFloat))
+		c.Set("user_role", role)
+		c.Set("user_locale", locale)
+		c.Set(authSchemeKey, scheme)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// RequireRole ต้องวางหลัง JWTAuthMiddleware
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("user_role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		abortWithError(c, apperror.Forbidden("insufficient permissions"))
+	}
+}
+
+func abortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ipxsandbox/internal/pkg/jwtutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthMiddlewareRestrictsPasswordChangeToken(t *testing.T) {
+	jwtManager := jwtutil.New("test-secret-0123456789abcdefghijklmnop", time.Minute, time.Hour)
+	restricted, err := jwtManager.GeneratePasswordChangeToken(7, "user", "")
+	require.NoError(t, err)
+	full, _, err := jwtManager.GenerateTokens(7, "user", "")
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(ErrorHandler(), JWTAuthMiddleware(jwtManager, newTestCookies(t), "POST /change-password"))
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.POST("/change-password", ok)
+	r.GET("/change-password", ok)
+	r.GET("/users", ok)
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodPost, "/change-password", restricted, http.StatusNoContent},
+		{http.MethodGet, "/change-password", restricted, http.StatusForbidden},
+		{http.MethodGet, "/users", restricted, http.StatusForbidden},
+		{http.MethodGet, "/users", full, http.StatusNoContent},
+		{http.MethodPost, "/change-password", full, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tt.want, w.Code, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestJWTAuthMiddlewareRejectsNonAccessTokens(t *testing.T) {
+	const secret = "test-secret-0123456789abcdefghijklmnop"
+	jwtManager := jwtutil.New(secret, time.Minute, time.Hour)
+	_, refresh, err := jwtManager.GenerateTokens(7, "admin", "")
+	require.NoError(t, err)
+	// token ที่ออกก่อนมี claim typ
+	untyped, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": 7, "role": "admin", "exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(ErrorHandler(), JWTAuthMiddleware(jwtManager, newTestCookies(t)))
+	r.GET("/users", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for name, token := range map[string]string{"refresh": refresh, "untyped": untyped} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "bearer")
+
+			req = httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "cookie")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestLogger ผูก request_id เข้ากับ logger แล้วเก็บไว้ใน context ของ request
+// และเขียน access log หนึ่งบรรทัดต่อ request ต้องวางหลัง RequestID
+// ถ้ามี span จาก middleware ของ tracing อยู่ก่อนหน้า จะใส่ trace_id ไว้ด้วยเพื่อโยง log กับ trace
+func RequestLogger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		l := base.With(slog.String("request_id", GetRequestID(c)))
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			l = l.With(slog.String("trace_id", sc.TraceID().String()))
+		}
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		l.LogAttrs(c.Request.Context(), level, "http request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int("bytes", c.Writer.Size()),
+		)
+	}
+}
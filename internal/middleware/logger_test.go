@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLoggerPropagatesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logger.NewWithWriter(logger.Config{Format: logger.FormatJSON}, &buf)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(RequestID(), RequestLogger(l))
+	r.GET("/users/:id", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Info("inside handler")
+		c.Status(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest("GET", "/users/42", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		assert.Equal(t, "abc-123", entry["request_id"])
+	}
+
+	var access map[string]any
+	require.NoError(t, json.Unmarshal(lines[1], &access))
+	assert.Equal(t, "/users/:id", access["route"])
+	assert.Equal(t, float64(http.StatusNoContent), access["status"])
+}
+
+func TestRequestIDGeneratedWhenInvalid(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get(RequestIDHeader)
+	assert.NotEmpty(t, id)
+	assert.NotContains(t, id, " ")
+}
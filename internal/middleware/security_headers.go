@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge เป็น 0 เพื่อไม่ส่ง Strict-Transport-Security
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// FrameAncestors คือค่าของ CSP frame-ancestors เช่น 'none' หรือ https://admin.example.com
+	FrameAncestors string
+	ReferrerPolicy string
+}
+
+var referrerPolicies = []string{
+	"no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+	"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url",
+}
+
+func (c SecurityHeadersConfig) Validate() error {
+	if c.HSTSMaxAge < 0 {
+		return errors.New("HSTS max age must not be negative")
+	}
+	if c.HSTSPreload && (c.HSTSMaxAge < 365*24*time.Hour || !c.HSTSIncludeSubdomains) {
+		return errors.New("HSTS preload requires a max age of at least one year and includeSubDomains")
+	}
+	if c.ReferrerPolicy != "" && !slices.Contains(referrerPolicies, c.ReferrerPolicy) {
+		return fmt.Errorf("unknown referrer policy %q", c.ReferrerPolicy)
+	}
+	return nil
+}
+
+// SecurityHeaders ใส่ header ป้องกันพื้นฐานให้ทุก response รวมถึง error ด้วย
+// browser ไม่สนใจ HSTS ที่ได้ผ่าน http จึงส่งได้เสมอแม้ TLS จะจบที่ proxy
+func SecurityHeaders(cfg SecurityHeadersConfig) gin.HandlerFunc {
+	headers := map[string]string{"X-Content-Type-Options": "nosniff"}
+	if cfg.HSTSMaxAge > 0 {
+		hsts := "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge.Seconds()), 10)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+	if cfg.FrameAncestors != "" {
+		headers["Content-Security-Policy"] = "frame-ancestors " + cfg.FrameAncestors
+		if strings.EqualFold(cfg.FrameAncestors, "'none'") {
+			// สำหรับ browser เก่าที่ยังไม่รองรับ frame-ancestors
+			headers["X-Frame-Options"] = "DENY"
+		}
+	}
+	if cfg.ReferrerPolicy != "" {
+		headers["Referrer-Policy"] = cfg.ReferrerPolicy
+	}
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		for k, v := range headers {
+			h.Set(k, v)
+		}
+		c.Next()
+	}
+}
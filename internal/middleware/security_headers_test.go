@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		FrameAncestors:        "'none'",
+		ReferrerPolicy:        "no-referrer",
+	}
+	assert.NoError(t, cfg.Validate())
+
+	r := gin.New()
+	r.Use(SecurityHeaders(cfg), ErrorHandler())
+	r.GET("/missing", func(c *gin.Context) { c.Error(apperror.NotFound("not found")) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "frame-ancestors 'none'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+}
+
+func TestSecurityHeadersWithoutHSTS(t *testing.T) {
+	r := gin.New()
+	r.Use(SecurityHeaders(SecurityHeadersConfig{FrameAncestors: "https://admin.example.com"}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+	assert.Empty(t, w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "frame-ancestors https://admin.example.com", w.Header().Get("Content-Security-Policy"))
+}
+
+func TestSecurityHeadersValidate(t *testing.T) {
+	assert.Error(t, SecurityHeadersConfig{HSTSMaxAge: time.Hour, HSTSPreload: true}.Validate())
+	assert.Error(t, SecurityHeadersConfig{ReferrerPolicy: "sometimes"}.Validate())
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout ตั้ง deadline ให้ context ของ request ทุกงานที่ใช้ c.Request.Context()
+// เช่น query ฐานข้อมูลหรือคำสั่ง Redis จะถูกยกเลิกเมื่อเกินเวลา
+// handler ไม่ถูกหยุดกลางคัน แต่ error ที่ได้จะถูกตอบเป็น 503 โดย ErrorHandler
+// d <= 0 คือไม่จำกัดเวลา
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
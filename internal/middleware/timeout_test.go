@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForDeadline(c *gin.Context) {
+	<-c.Request.Context().Done()
+	c.Error(c.Request.Context().Err())
+}
+
+func TestTimeoutRendersServiceUnavailable(t *testing.T) {
+	r := gin.New()
+	r.Use(ErrorHandler(), Timeout(10*time.Millisecond))
+	r.GET("/slow", waitForDeadline)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, apperror.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"timeout"`)
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	r := gin.New()
+	r.Use(Timeout(0))
+	r.GET("/", func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		assert.False(t, ok)
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestClientCanceledRequestIsNotAProblem(t *testing.T) {
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/slow", waitForDeadline)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+
+	assert.Equal(t, statusClientClosedRequest, w.Code)
+	assert.Empty(t, w.Body.String())
+}
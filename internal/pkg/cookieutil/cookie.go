@@ -0,0 +1,175 @@
+package cookieutil
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
+)
+
+const (
+	accessTokenName  = "access_token"
+	refreshTokenName = "refresh_token"
+	csrfTokenName    = "csrf_token"
+
+	csrfTokenBytes = 32
+
+	hostPrefix   = "__Host-"
+	securePrefix = "__Secure-"
+)
+
+var (
+	ErrUnknownSameSite  = errors.New("unknown SameSite mode")
+	ErrInsecureSameSite = errors.New("SameSite=None requires Secure cookies")
+	ErrInvalidPrefix    = errors.New("__Host- prefix requires Secure, no domain and path /")
+)
+
+type Config struct {
+	// Domain ว่างไว้ให้ cookie ผูกกับ host ที่ตอบ request เท่านั้น
+	Domain   string
+	Secure   bool
+	SameSite string
+	Path     string
+	// RefreshPath จำกัดให้ browser ส่ง refresh token ไปเฉพาะ endpoint ที่ขอ access token ใหม่
+	RefreshPath string
+	// HostPrefix ตั้งชื่อ access token และ CSRF token เป็น __Host- และ refresh token เป็น __Secure-
+	// (__Host- ใช้กับ path อื่นนอกจาก / ไม่ได้) เพื่อกัน subdomain อื่นเขียน cookie ทับ
+	HostPrefix    bool
+	AccessMaxAge  time.Duration
+	RefreshMaxAge time.Duration
+}
+
+// Policy กำหนดชื่อและ attribute ของ cookie ที่เก็บ token ให้ตรงกันทั้งตอนเขียนและอ่าน
+type Policy struct {
+	cfg         Config
+	sameSite    http.SameSite
+	accessName  string
+	refreshName string
+	csrfName    string
+}
+
+func New(cfg Config) (*Policy, error) {
+	p := &Policy{cfg: cfg, accessName: accessTokenName, refreshName: refreshTokenName, csrfName: csrfTokenName}
+	switch strings.ToLower(cfg.SameSite) {
+	case "", SameSiteLax:
+		p.sameSite = http.SameSiteLaxMode
+	case SameSiteStrict:
+		p.sameSite = http.SameSiteStrictMode
+	case SameSiteNone:
+		if !cfg.Secure {
+			return nil, ErrInsecureSameSite
+		}
+		p.sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSameSite, cfg.SameSite)
+	}
+
+	if p.cfg.Path == "" {
+		p.cfg.Path = "/"
+	}
+	if p.cfg.RefreshPath == "" {
+		p.cfg.RefreshPath = p.cfg.Path
+	}
+	if cfg.HostPrefix {
+		if !cfg.Secure || cfg.Domain != "" || p.cfg.Path != "/" {
+			return nil, ErrInvalidPrefix
+		}
+		p.accessName = hostPrefix + accessTokenName
+		p.csrfName = hostPrefix + csrfTokenName
+		p.refreshName = securePrefix + refreshTokenName
+		if p.cfg.RefreshPath == "/" {
+			p.refreshName = hostPrefix + refreshTokenName
+		}
+	}
+
+	if cfg.AccessMaxAge <= 0 || cfg.RefreshMaxAge <= 0 {
+		return nil, errors.New("cookie max age must be positive")
+	}
+	return p, nil
+}
+
+func (p *Policy) AccessTokenName() string {
+	return p.accessName
+}
+
+func (p *Policy) RefreshTokenName() string {
+	return p.refreshName
+}
+
+func (p *Policy) CSRFTokenName() string {
+	return p.csrfName
+}
+
+func (p *Policy) SetAccessToken(w http.ResponseWriter, token string) {
+	p.set(w, p.accessName, token, p.cfg.Path, p.cfg.AccessMaxAge, true)
+}
+
+func (p *Policy) SetRefreshToken(w http.ResponseWriter, token string) {
+	p.set(w, p.refreshName, token, p.cfg.RefreshPath, p.cfg.RefreshMaxAge, true)
+}
+
+// IssueCSRFToken ตั้ง CSRF token ใหม่ที่ JavaScript อ่านได้ เพื่อส่งกลับมาใน header (double-submit)
+// ใช้ตอน login เพื่อไม่ให้ token ที่มีอยู่ก่อนยืนยันตัวตนถูกใช้ต่อ
+// อายุเท่ากับ refresh token เพราะต้องใช้ได้ตลอดช่วงที่ยัง refresh ได้
+func (p *Policy) IssueCSRFToken(w http.ResponseWriter) (string, error) {
+	b := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate CSRF token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	p.set(w, p.csrfName, token, p.cfg.Path, p.cfg.RefreshMaxAge, false)
+	return token, nil
+}
+
+// RenewCSRFToken ต่ออายุ token เดิม ไม่ให้ request อื่นที่กำลังส่งอยู่ล้มเหลว และออกใหม่ถ้ายังไม่มี
+func (p *Policy) RenewCSRFToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	token := p.CSRFToken(r)
+	if token == "" {
+		return p.IssueCSRFToken(w)
+	}
+	p.set(w, p.csrfName, token, p.cfg.Path, p.cfg.RefreshMaxAge, false)
+	return token, nil
+}
+
+// AccessToken และ RefreshToken คืนค่าว่างเมื่อไม่มี cookie
+func (p *Policy) AccessToken(r *http.Request) string {
+	return value(r, p.accessName)
+}
+
+func (p *Policy) RefreshToken(r *http.Request) string {
+	return value(r, p.refreshName)
+}
+
+func (p *Policy) CSRFToken(r *http.Request) string {
+	return value(r, p.csrfName)
+}
+
+func (p *Policy) set(w http.ResponseWriter, name, value, path string, maxAge time.Duration, httpOnly bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   p.cfg.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   p.cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: p.sameSite,
+	})
+}
+
+func value(r *http.Request, name string) string {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
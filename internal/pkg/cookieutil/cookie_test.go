@@ -0,0 +1,104 @@
+package cookieutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{
+		Domain:        "example.com",
+		Secure:        true,
+		SameSite:      SameSiteStrict,
+		RefreshPath:   "/refresh-token",
+		AccessMaxAge:  15 * time.Minute,
+		RefreshMaxAge: 7 * 24 * time.Hour,
+	}
+}
+
+func TestSetTokens(t *testing.T) {
+	p, err := New(testConfig())
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	p.SetAccessToken(w, "access")
+	p.SetRefreshToken(w, "refresh")
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 2)
+	access, refresh := cookies[0], cookies[1]
+
+	assert.Equal(t, "access_token", access.Name)
+	assert.Equal(t, "/", access.Path)
+	assert.Equal(t, "example.com", access.Domain)
+	assert.Equal(t, 900, access.MaxAge)
+	assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+	assert.True(t, access.Secure)
+	assert.True(t, access.HttpOnly)
+
+	assert.Equal(t, "refresh_token", refresh.Name)
+	assert.Equal(t, "/refresh-token", refresh.Path)
+	assert.Equal(t, 7*24*60*60, refresh.MaxAge)
+}
+
+func TestHostPrefix(t *testing.T) {
+	cfg := testConfig()
+	cfg.Domain = ""
+	cfg.HostPrefix = true
+	p, err := New(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "__Host-access_token", p.AccessTokenName())
+	assert.Equal(t, "__Secure-refresh_token", p.RefreshTokenName())
+
+	req := httptest.NewRequest(http.MethodPost, "/refresh-token", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "planted"})
+	req.AddCookie(&http.Cookie{Name: "__Secure-refresh_token", Value: "real"})
+	assert.Equal(t, "real", p.RefreshToken(req))
+	assert.Empty(t, p.AccessToken(req))
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	cfg := testConfig()
+	cfg.HostPrefix = true
+	_, err := New(cfg)
+	assert.ErrorIs(t, err, ErrInvalidPrefix, "domain is not allowed with __Host-")
+
+	cfg = testConfig()
+	cfg.Secure = false
+	cfg.SameSite = SameSiteNone
+	_, err = New(cfg)
+	assert.ErrorIs(t, err, ErrInsecureSameSite)
+
+	cfg = testConfig()
+	cfg.SameSite = "sometimes"
+	_, err = New(cfg)
+	assert.ErrorIs(t, err, ErrUnknownSameSite)
+}
+
+func TestCSRFToken(t *testing.T) {
+	p, err := New(testConfig())
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	token, err := p.IssueCSRFToken(w)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	c := w.Result().Cookies()[0]
+	assert.Equal(t, "csrf_token", c.Name)
+	assert.False(t, c.HttpOnly, "JavaScript must be able to read the CSRF token")
+
+	req := httptest.NewRequest(http.MethodPost, "/refresh-token", nil)
+	req.AddCookie(c)
+	renewed, err := p.RenewCSRFToken(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	assert.Equal(t, token, renewed)
+
+	other, err := p.IssueCSRFToken(httptest.NewRecorder())
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
@@ -0,0 +1,53 @@
+// Package dbrouting เลือกว่า query จะไปที่ primary หรือ read replica
+//
+// โดยปกติ dbresolver ส่งการอ่านไป replica ซึ่งอาจยังไม่มีข้อมูลที่เพิ่งเขียน (replication lag)
+// request ที่ผ่าน Track จะอ่านจาก primary ทันทีหลังเขียนครั้งแรก
+// ส่วน WithPrimary ใช้บังคับอ่านจาก primary สำหรับงานที่ต้องการข้อมูลล่าสุดเสมอ
+package dbrouting
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type trackerKey struct{}
+
+type primaryKey struct{}
+
+// Track ติดตัวบันทึกการเขียนไว้ใน ctx ให้การอ่านหลังเขียนใน ctx เดียวกันไปที่ primary
+func Track(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trackerKey{}, new(atomic.Bool))
+}
+
+// WithPrimary บังคับให้ทุกการอ่านใน ctx ไปที่ primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	if forced, _ := ctx.Value(primaryKey{}).(bool); forced {
+		return true
+	}
+	wrote, ok := ctx.Value(trackerKey{}).(*atomic.Bool)
+	return ok && wrote.Load()
+}
+
+// Reader คืน db สำหรับอ่าน ซึ่งจะไป replica เว้นแต่ ctx ต้องการ primary
+func Reader(ctx context.Context, db *gorm.DB) *gorm.DB {
+	db = db.WithContext(ctx)
+	if usePrimary(ctx) {
+		return db.Clauses(dbresolver.Write)
+	}
+	return db
+}
+
+// Writer คืน db สำหรับเขียน และบันทึกว่า ctx นี้เคยเขียนแล้ว
+func Writer(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if wrote, ok := ctx.Value(trackerKey{}).(*atomic.Bool); ok {
+		wrote.Store(true)
+	}
+	return db.WithContext(ctx)
+}
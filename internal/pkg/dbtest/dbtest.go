@@ -0,0 +1,81 @@
+// Package dbtest เปิดฐานข้อมูลที่รัน migration แล้วสำหรับเทสของ repository
+//
+// ค่าเริ่มต้นเป็น sqlite ในหน่วยความจำแยกต่อเทส จึงรันได้โดยไม่ต้องมีฐานข้อมูลจริง
+// ตั้ง TEST_DATABASE_URL (เช่น postgres://... หรือ mysql://...) เพื่อรันเทสชุดเดียวกันกับฐานข้อมูลนั้น
+// โดย schema จะถูกล้างและสร้างใหม่ก่อนทุกเทส จึงต้องรันด้วย go test -p 1
+package dbtest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ipxsandbox/config"
+	"github.com/ipxsandbox/internal/pkg/migrate"
+	"github.com/ipxsandbox/migrations"
+	"gorm.io/gorm"
+)
+
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	cfg := config.DatabaseConfig{URL: os.Getenv("TEST_DATABASE_URL")}
+	if cfg.URL == "" {
+		cfg = memoryConfig(t.Name())
+	}
+	return open(t, cfg)
+}
+
+// OpenWithReplica คืน primary ที่ลงทะเบียน read replica แยกอีกฐานข้อมูลหนึ่งไว้แล้ว พร้อม replica เอง
+// ข้อมูลที่เขียนผ่าน primary จะไม่ปรากฏใน replica ใช้จำลอง replication lag
+// รองรับเฉพาะ sqlite จึงข้ามเทสเมื่อตั้ง TEST_DATABASE_URL
+func OpenWithReplica(t testing.TB) (primary, replica *gorm.DB) {
+	t.Helper()
+	if os.Getenv("TEST_DATABASE_URL") != "" {
+		t.Skip("replica routing test runs only against sqlite")
+	}
+
+	replicaCfg := memoryConfig(t.Name() + "_replica")
+	replica = open(t, replicaCfg)
+
+	primaryCfg := memoryConfig(t.Name())
+	primaryCfg.ReplicaURLs = []string{replicaCfg.Name}
+	primary = open(t, primaryCfg)
+	return primary, replica
+}
+
+func memoryConfig(name string) config.DatabaseConfig {
+	name = strings.NewReplacer("/", "_", " ", "_").Replace(name)
+	return config.DatabaseConfig{Driver: config.DriverSQLite, Name: "file:" + name + "?mode=memory&cache=shared"}
+}
+
+func open(t testing.TB, cfg config.DatabaseConfig) *gorm.DB {
+	t.Helper()
+	external := cfg.Driver != config.DriverSQLite
+
+	ctx := context.Background()
+	db, err := config.InitDB(ctx, cfg)
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	m, err := migrate.New(sqlDB, cfg.Dialect(), migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if external {
+		if _, err := m.Down(ctx, -1); err != nil {
+			t.Fatalf("reset test database: %v", err)
+		}
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,58 @@
+package emailutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// นโยบายของส่วนหน้า @ (local part) ส่วน domain เป็นตัวพิมพ์เล็กเสมอ
+const (
+	LocalPartLowercase = "lowercase"
+	LocalPartPreserve  = "preserve"
+)
+
+var (
+	ErrInvalid       = errors.New("invalid email address")
+	ErrUnknownPolicy = errors.New("unknown email local part policy")
+)
+
+// Normalizer แปลง email ให้อยู่ในรูปเดียวกันก่อนบันทึกหรือค้นหา
+// ตามมาตรฐาน local part แยกตัวพิมพ์เล็กใหญ่ได้ จึงให้เลือกได้ว่าจะเก็บตามที่ผู้ใช้พิมพ์หรือไม่
+// แต่ไม่ว่าจะเลือกแบบไหน unique index ก็เทียบแบบไม่สนตัวพิมพ์เล็กใหญ่ (ดู Key)
+type Normalizer struct {
+	preserveLocal bool
+}
+
+func New(localPartPolicy string) (Normalizer, error) {
+	switch strings.ToLower(localPartPolicy) {
+	case "", LocalPartLowercase:
+		return Normalizer{}, nil
+	case LocalPartPreserve:
+		return Normalizer{preserveLocal: true}, nil
+	}
+	return Normalizer{}, fmt.Errorf("%w: %q", ErrUnknownPolicy, localPartPolicy)
+}
+
+// Normalize ตัดช่องว่างหัวท้าย แปลง domain เป็นตัวพิมพ์เล็ก และจัดการ local part ตามนโยบาย
+func (n Normalizer) Normalize(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalid
+	}
+	local, domain := email[:at], strings.ToLower(strings.TrimSuffix(email[at+1:], "."))
+	if domain == "" {
+		return "", ErrInvalid
+	}
+	if !n.preserveLocal {
+		local = strings.ToLower(local)
+	}
+	return local + "@" + domain, nil
+}
+
+// Key คือตัวตนของ email ที่ใช้เทียบว่าซ้ำกันหรือไม่ ตรงกับ unique index ของตาราง users
+// ใช้เป็น key ของ rate limit ด้วย เพื่อไม่ให้เปลี่ยนตัวพิมพ์แล้วได้ตัวนับใหม่
+func Key(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
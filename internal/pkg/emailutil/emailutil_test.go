@@ -0,0 +1,46 @@
+package emailutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		policy string
+		in     string
+		want   string
+	}{
+		{LocalPartLowercase, "  Alice@Example.COM ", "alice@example.com"},
+		{LocalPartLowercase, "bob@example.com.", "bob@example.com"},
+		{LocalPartPreserve, "Alice.Smith@Example.COM", "Alice.Smith@example.com"},
+		{LocalPartPreserve, `"a@b"@Example.com`, `"a@b"@example.com`},
+	}
+	for _, tt := range tests {
+		n, err := New(tt.policy)
+		require.NoError(t, err)
+		got, err := n.Normalize(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestNormalizeRejectsInvalid(t *testing.T) {
+	n, err := New("")
+	require.NoError(t, err)
+	for _, in := range []string{"", "   ", "alice", "@example.com", "alice@", "alice@."} {
+		_, err := n.Normalize(in)
+		assert.ErrorIs(t, err, ErrInvalid, in)
+	}
+}
+
+func TestNewRejectsUnknownPolicy(t *testing.T) {
+	_, err := New("gmail")
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
+
+func TestKeyIgnoresCase(t *testing.T) {
+	assert.Equal(t, Key("Alice@Example.com"), Key(" alice@example.COM"))
+}
@@ -0,0 +1,94 @@
+package hashutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrMismatch           = errors.New("password does not match hash")
+	ErrUnknownAlgorithm   = errors.New("unknown password hash algorithm")
+	ErrInvalidHashEncoded = errors.New("invalid encoded password hash")
+)
+
+// PasswordHasher สร้างและตรวจสอบ hash ของรหัสผ่านในรูปแบบ encoded string
+// ที่เก็บ algorithm และ parameter ไว้ในตัว (PHC / modular crypt format)
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) error
+	NeedsRehash(encoded string) bool
+}
+
+type Config struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// hasher hash ด้วย algorithm ที่ตั้งค่าไว้ แต่ยังตรวจสอบ hash เก่าของ algorithm อื่นได้
+// เพื่อให้ย้าย user เดิมมาใช้ค่าใหม่ได้ตอน login โดยไม่ต้อง reset รหัสผ่าน
+type hasher struct {
+	preferred  algorithm
+	algorithms []algorithm
+}
+
+type algorithm interface {
+	PasswordHasher
+	matches(encoded string) bool
+}
+
+func New(cfg Config) (PasswordHasher, error) {
+	bc, err := newBcrypt(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	ar, err := newArgon2id(cfg.Argon2)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &hasher{algorithms: []algorithm{bc, ar}}
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", AlgorithmBcrypt:
+		h.preferred = bc
+	case AlgorithmArgon2id:
+		h.preferred = ar
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+	return h, nil
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *hasher) Verify(password, encoded string) error {
+	alg, err := h.detect(encoded)
+	if err != nil {
+		return err
+	}
+	return alg.Verify(password, encoded)
+}
+
+func (h *hasher) NeedsRehash(encoded string) bool {
+	if !h.preferred.matches(encoded) {
+		return true
+	}
+	return h.preferred.NeedsRehash(encoded)
+}
+
+func (h *hasher) detect(encoded string) (algorithm, error) {
+	for _, alg := range h.algorithms {
+		if alg.matches(encoded) {
+			return alg, nil
+		}
+	}
+	return nil, ErrUnknownAlgorithm
+}
@@ -0,0 +1,87 @@
+package hashutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	h, err := New(Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+	require.NoError(t, err)
+
+	encoded, err := h.Hash("Secret#123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.NoError(t, h.Verify("Secret#123", encoded))
+	assert.ErrorIs(t, h.Verify("wrong", encoded), ErrMismatch)
+	assert.False(t, h.NeedsRehash(encoded))
+}
+
+func TestBcryptHashAndVerify(t *testing.T) {
+	h, err := New(Config{Algorithm: AlgorithmBcrypt, BcryptCost: 4})
+	require.NoError(t, err)
+
+	encoded, err := h.Hash("Secret#123")
+	require.NoError(t, err)
+
+	assert.NoError(t, h.Verify("Secret#123", encoded))
+	assert.ErrorIs(t, h.Verify("wrong", encoded), ErrMismatch)
+	assert.False(t, h.NeedsRehash(encoded))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	oldBcrypt, err := New(Config{Algorithm: AlgorithmBcrypt, BcryptCost: 4})
+	require.NoError(t, err)
+	bcryptHash, err := oldBcrypt.Hash("Secret#123")
+	require.NoError(t, err)
+
+	newBcrypt, err := New(Config{Algorithm: AlgorithmBcrypt, BcryptCost: 5})
+	require.NoError(t, err)
+	assert.True(t, newBcrypt.NeedsRehash(bcryptHash), "outdated bcrypt cost")
+
+	argon, err := New(Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+	require.NoError(t, err)
+	assert.True(t, argon.NeedsRehash(bcryptHash), "different algorithm")
+	assert.NoError(t, argon.Verify("Secret#123", bcryptHash), "old algorithm still verifies")
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	argonHash, err := argon.Hash("Secret#123")
+	require.NoError(t, err)
+	strongerArgon, err := New(Config{Algorithm: AlgorithmArgon2id, Argon2: stronger})
+	require.NoError(t, err)
+	assert.True(t, strongerArgon.NeedsRehash(argonHash), "outdated argon2id params")
+}
+
+func TestVerifyRejectsUnknownFormat(t *testing.T) {
+	h, err := New(Config{})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, h.Verify("x", "plaintext"), ErrUnknownAlgorithm)
+	assert.ErrorIs(t, h.Verify("x", "$argon2id$v=19$broken"), ErrInvalidHashEncoded)
+}
+
+func TestVerifyRejectsInvalidArgon2Params(t *testing.T) {
+	h, err := New(Config{})
+	require.NoError(t, err)
+
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		"$argon2id$v=19$m=1024,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$",
+	} {
+		assert.ErrorIs(t, h.Verify("x", encoded), ErrInvalidHashEncoded, encoded)
+	}
+}
+
+func TestNewRejectsUnknownAlgorithm(t *testing.T) {
+	_, err := New(Config{Algorithm: "md5"})
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
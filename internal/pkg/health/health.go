@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipxsandbox/internal/pkg/logger"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	fn      CheckFunc
+}
+
+// CheckResult ไม่มีข้อความ error เพราะ /readyz เปิดให้เรียกโดยไม่ต้อง login
+// รายละเอียดของ check ที่ล้มเหลวจะถูกเขียนลง log ฝั่ง server แทน
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// Registry เก็บ dependency check ที่ใช้ตัดสิน readiness
+// subsystem ใดต้องการให้ readiness ขึ้นกับตัวเองก็ Register เพิ่มได้
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(name string, timeout time.Duration, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, timeout: timeout, fn: fn})
+}
+
+// SetShuttingDown ทำให้ readiness ตอบ not-ready ตลอดไป ใช้ตอนเริ่ม graceful shutdown
+// เพื่อให้ load balancer หยุดส่ง traffic ใหม่เข้ามา
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check รันทุก check พร้อมกัน แต่ละตัวมี timeout ของตัวเอง
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks)+1)}
+	if r.shuttingDown.Load() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = CheckResult{Status: StatusDown}
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			result := run(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, c check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+	result := CheckResult{Status: StatusUp, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		logger.FromContext(ctx).Warn("Readiness check failed", slog.String("check", c.name), slog.Any("error", err))
+	}
+	return result
+}
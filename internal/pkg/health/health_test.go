@@ -0,0 +1,58 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReportsEachDependency(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", time.Second, func(context.Context) error { return nil })
+	r.Register("redis", time.Second, func(context.Context) error { return errors.New("connection refused") })
+
+	var buf bytes.Buffer
+	ctx := logger.WithContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)).With("request_id", "req-1"))
+	report := r.Check(ctx)
+
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusUp, report.Checks["postgres"].Status)
+	assert.Equal(t, StatusDown, report.Checks["redis"].Status)
+	// error ไปอยู่ใน log พร้อม request_id ไม่ใช่ใน report ที่ส่งออกไป
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+	assert.Contains(t, buf.String(), `"error":"connection refused"`)
+}
+
+func TestCheckAppliesPerCheckTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register("slow", 20*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r.Register("fast", time.Second, func(context.Context) error { return nil })
+
+	start := time.Now()
+	report := r.Check(context.Background())
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StatusDown, report.Checks["slow"].Status)
+	assert.Equal(t, StatusUp, report.Checks["fast"].Status)
+}
+
+func TestShuttingDownIsNotReady(t *testing.T) {
+	r := NewRegistry()
+	r.Register("postgres", time.Second, func(context.Context) error { return nil })
+	assert.True(t, r.Check(context.Background()).Ready())
+
+	r.SetShuttingDown()
+
+	report := r.Check(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusDown, report.Checks["shutdown"].Status)
+}
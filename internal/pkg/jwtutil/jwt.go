@@ -1,40 +1,71 @@
 package jwtutil
 
 import (
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// claim typ บอกชนิดของ token ไม่ให้ใช้ refresh token ที่อายุยาวแทน access token
+// และไม่ให้ token ที่ใช้ได้แค่เปลี่ยนรหัสผ่านถูกนำไปขอ token ใหม่
+const (
+	ClaimType          = "typ"
+	TypeAccess         = "access"
+	TypeRefresh        = "refresh"
+	TypePasswordChange = "password_change"
+)
 
-func GenerateTokens(userID uint) (accessToken string, refreshToken string, err error) {
-	accessTokenClaims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Minute * 15).Unix(),
-	}
-	access := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
+type Manager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func New(secret string, accessTTL, refreshTTL time.Duration) *Manager {
+	return &Manager{secret: []byte(secret), accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+func (m *Manager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+func (m *Manager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
 
-	accessToken, err = access.SignedString(jwtSecret)
+// locale ถูกใส่ไว้ใน token เพื่อให้ request ที่ยืนยันตัวตนแล้วใช้ภาษาตามโปรไฟล์โดยไม่ต้องอ่านฐานข้อมูล
+func (m *Manager) GenerateTokens(userID uint, role, locale string) (accessToken string, refreshToken string, err error) {
+	accessToken, err = m.GenerateAccessToken(userID, role, locale)
 	if err != nil {
 		return
 	}
+	refreshToken, err = m.sign(userID, role, locale, TypeRefresh, m.refreshTTL)
+	return
+}
+
+func (m *Manager) GenerateAccessToken(userID uint, role, locale string) (string, error) {
+	return m.sign(userID, role, locale, TypeAccess, m.accessTTL)
+}
 
-	refreshTokenClaims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Hour * 24 * 7).Unix(),
+// GeneratePasswordChangeToken ออก access token ที่ใช้ได้แค่เปลี่ยนรหัสผ่าน ให้ user ที่ต้องเปลี่ยนรหัสผ่านก่อน
+// ไม่มี refresh token คู่กัน เมื่อเปลี่ยนรหัสผ่านแล้วต้อง login ใหม่เพื่อรับ token ปกติ
+func (m *Manager) GeneratePasswordChangeToken(userID uint, role, locale string) (string, error) {
+	return m.sign(userID, role, locale, TypePasswordChange, m.accessTTL)
+}
+
+func (m *Manager) sign(userID uint, role, locale, typ string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":     userID,
+		"role":    role,
+		"locale":  locale,
+		ClaimType: typ,
+		"exp":     time.Now().Add(ttl).Unix(),
 	}
-	refresh := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims)
-    refreshToken, err = refresh.SignedString(jwtSecret)
-	if err != nil {
-        return
-    }
-    return
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
 }
 
-func ParseToken(tokenStr string) (*jwt.Token, error) {
+func (m *Manager) ParseToken(tokenStr string) (*jwt.Token, error) {
 	return jwt.ParseWithClaims(tokenStr, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtSecret), nil
-	})
-}
\ No newline at end of file
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewWithWriter(Config{Level: "info", Format: FormatJSON}, &buf)
+	require.NoError(t, err)
+
+	l.Info("login",
+		"email", "alice@example.com",
+		"password", "Secret#123",
+		"refresh_token", "eyJhbGciOi",
+		"Set-Cookie", "access_token=abc",
+	)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "alice@example.com", entry["email"])
+	assert.Equal(t, redacted, entry["password"])
+	assert.Equal(t, redacted, entry["refresh_token"])
+	assert.Equal(t, redacted, entry["Set-Cookie"])
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewWithWriter(Config{Format: FormatJSON}, &buf)
+	require.NoError(t, err)
+
+	ctx := WithContext(context.Background(), l.With("request_id", "req-1"))
+	FromContext(ctx).Info("hello")
+
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+	assert.NotNil(t, FromContext(context.Background()))
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	_, err := New(Config{Level: "loud"})
+	assert.Error(t, err)
+
+	_, err = New(Config{Format: "xml"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/ipxsandbox/internal/audit"
+	"github.com/ipxsandbox/internal/entity"
+)
+
+type auditSink struct {
+	m *Metrics
+}
+
+// AuditSink นับ event ด้าน auth จาก audit log จึงไม่ต้องกระจายโค้ดนับไปตาม handler
+func (m *Metrics) AuditSink() audit.Sink {
+	return &auditSink{m: m}
+}
+
+func (s *auditSink) Write(ctx context.Context, event entity.AuditEvent) error {
+	switch event.Action {
+	case audit.ActionLogin:
+		s.m.logins.WithLabelValues(event.Outcome).Inc()
+	case audit.ActionTokenRefresh:
+		s.m.refreshes.WithLabelValues(event.Outcome).Inc()
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/audit"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/middleware"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareUsesRouteTemplate(t *testing.T) {
+	m := New()
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/users/1", "/users/2", "/nope/alice@example.com"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `ipxsandbox_http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`)
+	assert.Contains(t, body, `route="unmatched",status="404"`)
+	assert.NotContains(t, body, "alice@example.com")
+}
+
+func TestMiddlewareRecordsErrorHandlerStatus(t *testing.T) {
+	m := New()
+	r := gin.New()
+	r.Use(m.Middleware(), middleware.ErrorHandler())
+	r.GET("/me", func(c *gin.Context) { _ = c.Error(apperror.Unauthorized("missing token")) })
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, scrape(t, m), `ipxsandbox_http_request_duration_seconds_count{method="GET",route="/me",status="401"} 1`)
+}
+
+func TestAuditSinkCountsAuthOutcomes(t *testing.T) {
+	m := New()
+	recorder := audit.NewRecorder(m.AuditSink())
+	ctx := context.Background()
+
+	recorder.Record(ctx, entity.AuditEvent{Action: audit.ActionLogin, Outcome: audit.OutcomeFailure, Target: "alice@example.com"})
+	recorder.Record(ctx, entity.AuditEvent{Action: audit.ActionLogin, Outcome: audit.OutcomeFailure, Target: "bob@example.com"})
+	recorder.Record(ctx, entity.AuditEvent{Action: audit.ActionLogin, Outcome: audit.OutcomeBlocked})
+	recorder.Record(ctx, entity.AuditEvent{Action: audit.ActionTokenRefresh, Outcome: audit.OutcomeSuccess})
+	recorder.Record(ctx, entity.AuditEvent{Action: audit.ActionUserCreate, Outcome: audit.OutcomeSuccess})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.logins.WithLabelValues(audit.OutcomeFailure)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.logins.WithLabelValues(audit.OutcomeBlocked)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.refreshes.WithLabelValues(audit.OutcomeSuccess)))
+	assert.NotContains(t, scrape(t, m), "alice@example.com")
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.NoError(t, err)
+	m.Handler().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return strings.TrimSpace(w.Body.String())
+}
@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// mysqlLockTimeout คือจำนวนวินาทีที่รอ GET_LOCK ก่อนยอมแพ้
+const mysqlLockTimeout = 600
+
+// lockKey เป็นค่าคงที่ของ advisory lock ที่ทุก replica ของ service นี้ใช้ร่วมกัน
+const (
+	lockKey  int64 = 0x69707873616e64 // "ipxsand"
+	lockName       = "ipxsandbox_migrate"
+)
+
+type dialect struct {
+	createHistory string
+	insertHistory string
+	deleteHistory string
+	// splitStatements แยกสคริปต์เป็นทีละคำสั่ง สำหรับ driver ที่ Exec หลายคำสั่งพร้อมกันไม่ได้
+	splitStatements bool
+	lock            func(ctx context.Context, conn *sql.Conn) (unlock func(context.Context) error, err error)
+}
+
+var dialects = map[string]dialect{
+	DialectPostgres: {
+		createHistory: "CREATE TABLE IF NOT EXISTS " + historyTable + " (version BIGINT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL)",
+		insertHistory: "INSERT INTO " + historyTable + " (version, name, applied_at) VALUES ($1, $2, $3)",
+		deleteHistory: "DELETE FROM " + historyTable + " WHERE version = $1",
+		lock: func(ctx context.Context, conn *sql.Conn) (func(context.Context) error, error) {
+			// pg_advisory_lock รอจน replica อื่นรันเสร็จ แล้วค่อยเห็นว่าไม่มีอะไรต้องรันต่อ
+			if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context) error {
+				_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+				return err
+			}, nil
+		},
+	},
+	// DDL ของ MySQL commit ตัวเองทันที migration ที่ล้มเหลวกลางทางจึงอาจค้างครึ่งเดียว
+	// ควรเขียนหนึ่งคำสั่งต่อ migration
+	DialectMySQL: {
+		createHistory:   "CREATE TABLE IF NOT EXISTS " + historyTable + " (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at DATETIME(6) NOT NULL)",
+		insertHistory:   "INSERT INTO " + historyTable + " (version, name, applied_at) VALUES (?, ?, ?)",
+		deleteHistory:   "DELETE FROM " + historyTable + " WHERE version = ?",
+		splitStatements: true,
+		lock: func(ctx context.Context, conn *sql.Conn) (func(context.Context) error, error) {
+			var got sql.NullInt64
+			if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, mysqlLockTimeout).Scan(&got); err != nil {
+				return nil, err
+			}
+			if got.Int64 != 1 {
+				return nil, errors.New("timed out waiting for another migration to finish")
+			}
+			return func(ctx context.Context) error {
+				_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+				return err
+			}, nil
+		},
+	},
+	DialectSQLite: {
+		createHistory: "CREATE TABLE IF NOT EXISTS " + historyTable + " (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME NOT NULL)",
+		insertHistory: "INSERT INTO " + historyTable + " (version, name, applied_at) VALUES (?, ?, ?)",
+		deleteHistory: "DELETE FROM " + historyTable + " WHERE version = ?",
+		// sqlite ใช้กับ process เดียว และ transaction ของแต่ละ migration ถูกล็อกระดับไฟล์อยู่แล้ว
+		lock: func(context.Context, *sql.Conn) (func(context.Context) error, error) {
+			return func(context.Context) error { return nil }, nil
+		},
+	},
+}
+
+func Dialects() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,315 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyTable = "schema_migrations"
+
+var (
+	ErrUnknownDialect  = errors.New("unknown migration dialect")
+	ErrInvalidName     = errors.New("migration name must contain only letters, digits and underscores")
+	ErrMissingDownFile = errors.New("migration has no down file")
+	ErrUnknownApplied  = errors.New("applied migration has no file")
+)
+
+var (
+	fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	nameRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator รัน migration จากไฟล์ SQL ของ dialect ที่กำหนด และบันทึกประวัติไว้ในตาราง schema_migrations
+// ทุกคำสั่งที่เปลี่ยน schema ทำภายใต้ lock เพื่อให้หลาย replica รันพร้อมกันได้โดยไม่ชนกัน
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// New อ่านไฟล์ migration จากโฟลเดอร์ชื่อเดียวกับ dialect ภายใน fsys
+func New(db *sql.DB, dialectName string, fsys fs.FS) (*Migrator, error) {
+	d, ok := dialects[dialectName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDialect, dialectName)
+	}
+	sub, err := fs.Sub(fsys, dialectName)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := load(sub)
+	if err != nil {
+		return nil, fmt.Errorf("load %s migrations: %w", dialectName, err)
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations}, nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range names {
+		m := fileRe.FindStringSubmatch(file)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", file)
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse version of %q: %w", file, err)
+		}
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("version %d is used by both %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.up = string(body)
+		} else {
+			mig.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if strings.TrimSpace(mig.up) == "" {
+			return nil, fmt.Errorf("migration %s has an empty up file", mig)
+		}
+		if strings.TrimSpace(mig.down) == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingDownFile, mig)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up รัน migration ทุกตัวที่ยังไม่ได้รันตามลำดับ version และคืนรายการที่รันไป
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, mig.up, m.dialect.insertHistory, mig.Version, mig.Name, time.Now().UTC()); err != nil {
+				return fmt.Errorf("apply %s: %w", mig, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down ย้อน migration ที่รันล่าสุดกลับ steps ตัว ถ้า steps < 0 จะย้อนทั้งหมด
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if len(done) == steps {
+				break
+			}
+			mig, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("%w: version %d", ErrUnknownApplied, version)
+			}
+			if err := m.run(ctx, conn, mig.down, m.dialect.deleteHistory, mig.Version); err != nil {
+				return fmt.Errorf("roll back %s: %w", mig, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status คืนสถานะของ migration ทุกไฟล์ เรียงตาม version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := applied[mig.Version]
+		statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock ใช้ connection เดียวตลอด เพราะ advisory lock ของ postgres ผูกกับ session
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.dialect.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer unlock(context.WithoutCancel(ctx))
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	if _, err := conn.ExecContext(ctx, m.dialect.createHistory); err != nil {
+		return nil, fmt.Errorf("create %s: %w", historyTable, err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+historyTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version int64
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// run รัน SQL ของ migration และแก้ตารางประวัติใน transaction เดียวกัน
+// ถ้า SQL ล้มเหลวกลางทาง schema และประวัติจะไม่ถูกเปลี่ยนทั้งคู่
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script, history string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range m.statements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, history, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// statements แยกคำสั่งด้วย ; ที่อยู่ท้ายบรรทัด ซึ่งพอสำหรับไฟล์ migration ที่เขียนเอง
+func (m *Migrator) statements(script string) []string {
+	if !m.dialect.splitStatements {
+		return []string{script}
+	}
+	var stmts []string
+	for _, part := range strings.SplitAfter(script, ";\n") {
+		if stmt := strings.TrimSpace(part); stmt != "" && stmt != ";" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// Create สร้างไฟล์ up/down ว่างของ migration ใหม่ให้ทุก dialect ภายใต้ dir
+// version เป็นเวลา UTC เพื่อไม่ให้ migration จากคนละ branch ชนกัน
+func Create(dir, name string, now time.Time) ([]string, error) {
+	name = strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimSpace(name)))
+	if !nameRe.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	version := now.UTC().Format("20060102150405")
+
+	var created []string
+	for _, d := range Dialects() {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, d, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return created, err
+			}
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				return created, err
+			}
+			_, err = fmt.Fprintf(f, "-- %s (%s, %s)\n", name, d, direction)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return created, err
+			}
+			created = append(created, path)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,133 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ipxsandbox/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	m, err := New(db, DialectSQLite, migrations.FS)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+
+	again, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	_, err = db.ExecContext(ctx, "INSERT INTO users (name, email, password) VALUES ('a', 'a@example.com', 'x')")
+	require.NoError(t, err)
+	var role string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT role FROM users").Scan(&role))
+	assert.Equal(t, "user", role)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, s.String())
+		assert.False(t, s.AppliedAt.IsZero())
+	}
+
+	rolled, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rolled, 1)
+	assert.Equal(t, applied[len(applied)-1].Version, rolled[0].Version)
+
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+
+	rolled, err = m.Down(ctx, len(applied))
+	require.NoError(t, err)
+	assert.Len(t, rolled, len(applied)-1)
+	_, err = db.ExecContext(ctx, "SELECT 1 FROM users")
+	assert.Error(t, err)
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	fsys := fstest.MapFS{
+		"sqlite/1_ok.up.sql":     {Data: []byte("CREATE TABLE ok (id INTEGER);")},
+		"sqlite/1_ok.down.sql":   {Data: []byte("DROP TABLE ok;")},
+		"sqlite/2_bad.up.sql":    {Data: []byte("CREATE TABLE bad (id INTEGER); SELECT * FROM missing;")},
+		"sqlite/2_bad.down.sql":  {Data: []byte("DROP TABLE bad;")},
+		"postgres/1_ok.up.sql":   {Data: []byte("unused")},
+		"postgres/1_ok.down.sql": {Data: []byte("unused")},
+	}
+	m, err := New(db, DialectSQLite, fsys)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.Error(t, err)
+	assert.Len(t, applied, 1)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	_, err = db.ExecContext(ctx, "SELECT 1 FROM bad")
+	assert.Error(t, err, "schema change of a failed migration must be rolled back")
+}
+
+func TestLoadRejectsMissingDownFile(t *testing.T) {
+	fsys := fstest.MapFS{"sqlite/1_only_up.up.sql": {Data: []byte("SELECT 1;")}}
+	_, err := New(nil, DialectSQLite, fsys)
+	assert.ErrorIs(t, err, ErrMissingDownFile)
+}
+
+func TestNewRejectsUnknownDialect(t *testing.T) {
+	_, err := New(nil, "oracle", migrations.FS)
+	assert.ErrorIs(t, err, ErrUnknownDialect)
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	files, err := Create(dir, "Add user-locale", now)
+	require.NoError(t, err)
+	assert.Len(t, files, 2*len(Dialects()))
+	assert.FileExists(t, filepath.Join(dir, DialectPostgres, "20261019083000_add_user_locale.up.sql"))
+	assert.FileExists(t, filepath.Join(dir, DialectSQLite, "20261019083000_add_user_locale.down.sql"))
+
+	_, err = Create(dir, "Add user-locale", now)
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	_, err = Create(dir, "drop;table", now)
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
+
+func TestMySQLMigrationsLoadAndSplit(t *testing.T) {
+	m, err := New(nil, DialectMySQL, migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, m.migrations)
+
+	stmts := m.statements("CREATE TABLE a (id INT);\nCREATE INDEX idx_a ON a (id);\n")
+	assert.Equal(t, []string{"CREATE TABLE a (id INT);", "CREATE INDEX idx_a ON a (id);"}, stmts)
+}
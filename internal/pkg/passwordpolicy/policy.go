@@ -0,0 +1,97 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// MaxHistoryDepth จำกัดจำนวนรหัสผ่านเก่าที่ต้องเทียบ เพราะต้อง verify hash ทีละตัวตอนเปลี่ยนรหัสผ่าน
+const MaxHistoryDepth = 24
+
+// Policy คือเงื่อนไขของรหัสผ่านที่ใช้ตอนตั้งหรือเปลี่ยนรหัสผ่านเท่านั้น ไม่ใช้ตอน login
+// รหัสผ่านที่ตั้งไว้ก่อน policy เปลี่ยนจึงยัง login ได้ ความยาวนับเป็นจำนวนตัวอักษร
+type Policy struct {
+	MinLength      int  `json:"min_length"`
+	MaxLength      int  `json:"max_length"`
+	RequireUpper   bool `json:"require_upper"`
+	RequireLower   bool `json:"require_lower"`
+	RequireDigit   bool `json:"require_digit"`
+	RequireSpecial bool `json:"require_special"`
+	// MaxBytes คือความยาวสูงสุดนับเป็น byte ของ UTF-8 ตามที่ algorithm ของ hash รับได้ (0 = ไม่จำกัด)
+	// ตัวอักษรไทยใช้ 3 byte ต่อตัว จึงถึงขีดนี้ก่อน MaxLength ได้
+	MaxBytes int `json:"max_bytes"`
+	// MaxRepeated คือจำนวนตัวอักษรเดียวกันที่ติดกันได้มากที่สุด (0 = ไม่จำกัด)
+	MaxRepeated int `json:"max_repeated"`
+	// HistoryDepth คือจำนวนรหัสผ่านล่าสุดที่ห้ามนำกลับมาใช้ (0 = ไม่ตรวจ)
+	HistoryDepth int `json:"history_depth"`
+}
+
+// Default ตรงกับเงื่อนไขเดิมที่เคยกำหนดไว้ใน struct tag
+// ขีดจำกัดของ bcrypt นับเป็น byte จึงไม่อยู่ใน MaxLength แต่ config ตั้ง MaxBytes ให้เมื่อใช้ bcrypt
+func Default() Policy {
+	return Policy{
+		MinLength:      8,
+		MaxLength:      72,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+	}
+}
+
+func (p Policy) Validate() error {
+	var errs []error
+	if p.MinLength < 1 {
+		errs = append(errs, errors.New("min length must be at least 1"))
+	}
+	if p.MaxLength < p.MinLength {
+		errs = append(errs, errors.New("max length must not be less than min length"))
+	}
+	if p.MaxBytes < 0 {
+		errs = append(errs, errors.New("max bytes must not be negative"))
+	}
+	if p.MaxRepeated < 0 {
+		errs = append(errs, errors.New("max repeated characters must not be negative"))
+	}
+	if p.HistoryDepth < 0 || p.HistoryDepth > MaxHistoryDepth {
+		errs = append(errs, errors.New("history depth must be between 0 and 24"))
+	}
+	return errors.Join(errs...)
+}
+
+func HasUpper(s string) bool {
+	return strings.IndexFunc(s, unicode.IsUpper) >= 0
+}
+
+func HasLower(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLower) >= 0
+}
+
+func HasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+// HasSpecial นับทุกตัวที่ไม่ใช่ตัวอักษรหรือตัวเลข รวมถึงช่องว่าง
+// สระและวรรณยุกต์ไทยเป็น combining mark จึงไม่นับเป็นอักขระพิเศษ
+func HasSpecial(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	}) >= 0
+}
+
+// LongestRun คือจำนวนตัวอักษรเดียวกันที่ติดกันยาวที่สุดใน s
+func LongestRun(s string) int {
+	var longest, run int
+	var prev rune
+	for i, r := range []rune(s) {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
@@ -0,0 +1,39 @@
+package passwordpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+
+	p := Default()
+	p.MinLength, p.MaxLength, p.MaxBytes, p.MaxRepeated, p.HistoryDepth = 0, -1, -1, -1, MaxHistoryDepth+1
+	err := p.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "min length")
+		assert.Contains(t, err.Error(), "max length")
+		assert.Contains(t, err.Error(), "max bytes")
+		assert.Contains(t, err.Error(), "repeated")
+		assert.Contains(t, err.Error(), "history depth")
+	}
+}
+
+func TestCharacterClasses(t *testing.T) {
+	assert.True(t, HasUpper("abcD"))
+	assert.False(t, HasUpper("abc1!"))
+	assert.True(t, HasLower("ABCd"))
+	assert.True(t, HasDigit("abc1"))
+	assert.True(t, HasSpecial("abc def"))
+	assert.True(t, HasSpecial("abc_def"))
+	assert.False(t, HasSpecial("abcรหัส123"))
+}
+
+func TestLongestRun(t *testing.T) {
+	assert.Equal(t, 0, LongestRun(""))
+	assert.Equal(t, 1, LongestRun("abc"))
+	assert.Equal(t, 3, LongestRun("aabbbc"))
+	assert.Equal(t, 4, LongestRun("xรรรร"))
+}
@@ -1,21 +1,23 @@
 package redis
 
 import (
-	"context"
-	"os"
+	"fmt"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-var (
-	Rdb *redis.Client
-	Ctx = context.Background()
-)
+var Rdb *redis.Client
 
-func InitRedis() {
+func InitRedis(addr, password string, db int) error {
 	Rdb = redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDR"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       0,
+		Addr:     addr,
+		Password: password,
+		DB:       db,
 	})
-}
\ No newline at end of file
+	// ไม่บันทึกคำสั่งเต็มลง span เพราะ key มี email ของผู้ใช้อยู่
+	if err := redisotel.InstrumentTracing(Rdb, redisotel.WithDBStatement(false)); err != nil {
+		return fmt.Errorf("instrument redis tracing: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, event entity.AuditEvent) error {
+	// ใช้ db ตรงๆ แทน dbrouting.Writer เพราะการเขียน audit ไม่ควรทำให้การอ่านข้อมูลอื่นใน request ไป primary
+	return r.db.WithContext(ctx).Create(&event).Error
+}
+
+func (r *gormRepository) List(ctx context.Context, filter Filter) ([]entity.AuditEvent, int64, error) {
+	q := dbrouting.Reader(ctx, r.db).Model(&entity.AuditEvent{})
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if filter.Outcome != "" {
+		q = q.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.ActorID != nil {
+		q = q.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Target != "" {
+		q = q.Where("target = ?", filter.Target)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []entity.AuditEvent
+	err := q.Order("created_at DESC, id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&events).Error
+	return events, total, err
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFiltersAndPaginates(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	repo := New(db)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	actorID := uint(3)
+	events := []entity.AuditEvent{
+		{CreatedAt: base, Action: "auth.login", Outcome: "failure", Target: "a@example.com"},
+		{CreatedAt: base.Add(time.Minute), Action: "auth.login", Outcome: "success", Target: "a@example.com"},
+		{CreatedAt: base.Add(2 * time.Minute), Action: "user.create", Outcome: "success", ActorID: &actorID, Target: "user:9"},
+		{CreatedAt: base.Add(3 * time.Minute), Action: "auth.login", Outcome: "failure", Target: "b@example.com"},
+	}
+	for _, e := range events {
+		assert.NoError(t, repo.Create(ctx, e))
+	}
+
+	got, total, err := repo.List(ctx, Filter{Action: "auth.login", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "b@example.com", got[0].Target, "newest first")
+
+	got, total, err = repo.List(ctx, Filter{ActorID: &actorID, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "user:9", got[0].Target)
+
+	got, _, err = repo.List(ctx, Filter{From: base.Add(time.Minute), To: base.Add(3 * time.Minute), Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+}
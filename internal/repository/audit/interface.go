@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+)
+
+type Filter struct {
+	Action  string
+	Outcome string
+	ActorID *uint
+	Target  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Offset  int
+}
+
+// Repository ไม่มี Update หรือ Delete เพราะ audit log ต้องเป็น append-only
+type Repository interface {
+	Create(ctx context.Context, event entity.AuditEvent) error
+	List(ctx context.Context, filter Filter) ([]entity.AuditEvent, int64, error)
+}
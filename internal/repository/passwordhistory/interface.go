@@ -0,0 +1,15 @@
+package passwordhistory
+
+import (
+	"context"
+
+	"github.com/ipxsandbox/internal/entity"
+)
+
+type Repository interface {
+	Create(ctx context.Context, entry entity.PasswordHistory) error
+	// Recent คืนรหัสผ่านเดิมของ user ไม่เกิน limit รายการ เรียงจากล่าสุดไปเก่าสุด
+	Recent(ctx context.Context, userID uint, limit int) ([]entity.PasswordHistory, error)
+	// Prune ลบรายการที่เก่ากว่า keep รายการล่าสุดของแต่ละ user และคืนจำนวนที่ลบ
+	Prune(ctx context.Context, keep int) (int64, error)
+}
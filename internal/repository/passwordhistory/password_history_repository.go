@@ -0,0 +1,39 @@
+package passwordhistory
+
+import (
+	"context"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, entry entity.PasswordHistory) error {
+	return dbrouting.Writer(ctx, r.db).Create(&entry).Error
+}
+
+func (r *gormRepository) Recent(ctx context.Context, userID uint, limit int) ([]entity.PasswordHistory, error) {
+	var entries []entity.PasswordHistory
+	err := dbrouting.Reader(ctx, r.db).Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+// pruneSQL ห่อ subquery ไว้อีกชั้นเพราะ MySQL ไม่ให้อ้างตารางเดียวกับที่กำลังลบใน subquery ตรงๆ
+const pruneSQL = `DELETE FROM password_history WHERE id NOT IN (
+	SELECT id FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id DESC) AS rn FROM password_history
+	) ranked WHERE rn <= ?
+)`
+
+func (r *gormRepository) Prune(ctx context.Context, keep int) (int64, error) {
+	res := dbrouting.Writer(ctx, r.db).Exec(pruneSQL, keep)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,54 @@
+package passwordhistory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbtest"
+	"github.com/ipxsandbox/internal/repository/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentAndPrune(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	repo := New(db)
+	users := user.New(db)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []uint
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		u, err := users.Create(ctx, entity.User{Name: "Test", Email: email, Password: "x"})
+		require.NoError(t, err)
+		ids = append(ids, u.ID)
+		for i := range 4 {
+			entry := entity.PasswordHistory{UserID: u.ID, Password: fmt.Sprintf("hash-%d", i), CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+			require.NoError(t, repo.Create(ctx, entry))
+		}
+	}
+
+	got, err := repo.Recent(ctx, ids[0], 2)
+	assert.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "hash-3", got[0].Password, "newest first")
+	assert.Equal(t, "hash-2", got[1].Password)
+
+	deleted, err := repo.Prune(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "one entry per user beyond the newest three")
+
+	for _, id := range ids {
+		got, err := repo.Recent(ctx, id, 10)
+		assert.NoError(t, err)
+		require.Len(t, got, 3)
+		assert.Equal(t, "hash-1", got[2].Password)
+	}
+
+	deleted, err = repo.Prune(ctx, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), deleted)
+}
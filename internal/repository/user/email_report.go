@@ -0,0 +1,92 @@
+package user
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"gorm.io/gorm"
+)
+
+const emailScanBatchSize = 1000
+
+type EmailRow struct {
+	ID    uint
+	Email string
+}
+
+// EmailCollision คือกลุ่มบัญชีที่ email ต่างกันแค่ตัวพิมพ์เล็กใหญ่หรือช่องว่าง
+// ต้องให้คนตัดสินใจว่าจะรวมหรือเปลี่ยน email ของบัญชีไหน
+type EmailCollision struct {
+	Key   string
+	Users []EmailRow
+}
+
+// EmailChange คือ email ที่ยังไม่อยู่ในรูปที่ normalize แล้ว และแก้ได้โดยไม่ชนกับบัญชีอื่น
+type EmailChange struct {
+	ID   uint
+	From string
+	To   string
+}
+
+type EmailReport struct {
+	Collisions []EmailCollision
+	Changes    []EmailChange
+	// Invalid คือแถวที่ normalize ไม่ได้ เช่นไม่มี @
+	Invalid []EmailRow
+}
+
+// ScanEmails อ่าน email ทุกแถวจาก primary ทีละชุดแล้วจัดกลุ่มตาม emailutil.Key
+// ใช้ก่อนรัน migration ที่สร้าง unique index แบบไม่สนตัวพิมพ์ ซึ่งจะล้มเหลวถ้ามี email ชนกัน
+func ScanEmails(ctx context.Context, db *gorm.DB, normalizer emailutil.Normalizer) (EmailReport, error) {
+	groups := make(map[string][]EmailRow)
+	var batch []entity.User
+	err := dbrouting.Reader(dbrouting.WithPrimary(ctx), db).Select("id", "email").
+		FindInBatches(&batch, emailScanBatchSize, func(*gorm.DB, int) error {
+			for _, u := range batch {
+				key := emailutil.Key(u.Email)
+				groups[key] = append(groups[key], EmailRow{ID: u.ID, Email: u.Email})
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return EmailReport{}, err
+	}
+
+	var report EmailReport
+	for key, rows := range groups {
+		if len(rows) > 1 {
+			report.Collisions = append(report.Collisions, EmailCollision{Key: key, Users: rows})
+			continue
+		}
+		row := rows[0]
+		normalized, err := normalizer.Normalize(row.Email)
+		if err != nil {
+			report.Invalid = append(report.Invalid, row)
+			continue
+		}
+		if normalized != row.Email {
+			report.Changes = append(report.Changes, EmailChange{ID: row.ID, From: row.Email, To: normalized})
+		}
+	}
+
+	sort.Slice(report.Collisions, func(i, j int) bool { return report.Collisions[i].Key < report.Collisions[j].Key })
+	sort.Slice(report.Changes, func(i, j int) bool { return report.Changes[i].ID < report.Changes[j].ID })
+	sort.Slice(report.Invalid, func(i, j int) bool { return report.Invalid[i].ID < report.Invalid[j].ID })
+	return report, nil
+}
+
+// ApplyEmailChanges แก้ email ตาม changes ทั้งหมดใน transaction เดียว
+func ApplyEmailChanges(ctx context.Context, db *gorm.DB, changes []EmailChange) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, c := range changes {
+			err := tx.Model(&entity.User{}).Where("id = ? AND email = ?", c.ID, c.From).Update("email", c.To).Error
+			if err != nil {
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,55 @@
+package user
+
+import (
+	"errors"
+
+	sqlite "github.com/glebarez/go-sqlite"
+	"github.com/go-sql-driver/mysql"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound       = apperror.NotFound("user not found")
+	ErrDuplicateEmail = apperror.Conflict("email already exists")
+)
+
+// รหัส error ของ unique constraint ที่ถูกละเมิดในแต่ละ driver
+// ใช้เมื่อ gorm ไม่ได้เปิด TranslateError ไว้
+const (
+	pgUniqueViolation      = "23505"
+	mysqlDuplicateEntry    = 1062
+	sqliteConstraintUnique = 2067
+)
+
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound.WithCause(err)
+	case isUniqueViolation(err):
+		return ErrDuplicateEmail.WithCause(err)
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqliteConstraintUnique
+	}
+	return false
+}
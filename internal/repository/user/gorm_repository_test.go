@@ -1,35 +1,172 @@
 package user
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/ipxsandbox/internal/entity"
-	"github.com/glebarez/sqlite"
-	"gorm.io/gorm"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+	"github.com/ipxsandbox/internal/pkg/dbtest"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+func TestCreateAndFindAll(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	user := entity.User{Name: "Test User", Email: "test@example.com"}
+	createdUser, err := repo.Create(ctx, user)
 	assert.NoError(t, err)
+	assert.NotZero(t, createdUser.ID)
 
-	err = db.AutoMigrate(&entity.User{})
+	users, err := repo.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Test User", users[0].Name)
+}
+func TestCreateDuplicateEmail(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	_, err := repo.Create(ctx, entity.User{Name: "First", Email: "dup@example.com", Password: "x"})
 	assert.NoError(t, err)
 
-	return db
+	_, err = repo.Create(ctx, entity.User{Name: "Second", Email: "dup@example.com", Password: "x"})
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
 }
 
-func TestCreateAndFindAll(t *testing.T) {
-	db := setupTestDB(t)
+func TestFindByEmailNotFound(t *testing.T) {
+	db := dbtest.Open(t)
 	repo := New(db)
+	ctx := context.Background()
 
-	user := entity.User{Name: "Test User", Email: "test@example.com"}
-	createdUser, err := repo.Create(user)
+	_, err := repo.FindByEmail(ctx, "missing@example.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFindByID(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, entity.User{Name: "Test User", Email: "test@example.com", Password: "x", Locale: "th"})
+	require.NoError(t, err)
+
+	found, err := repo.FindByID(ctx, created.ID)
 	assert.NoError(t, err)
-	assert.NotZero(t, createdUser.ID)
+	// ฐานข้อมูลเก็บเวลาได้ละเอียดไม่เท่า time.Time และคืน location ต่างกัน
+	assert.WithinDuration(t, created.PasswordChangedAt, found.PasswordChangedAt, time.Millisecond)
+	found.PasswordChangedAt = created.PasswordChangedAt
+	assert.Equal(t, created, found)
+
+	_, err = repo.FindByID(ctx, created.ID+1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSetPasswordAndRequirePasswordChange(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, entity.User{Name: "Test User", Email: "test@example.com", Password: "x"})
+	require.NoError(t, err)
+	assert.False(t, created.PasswordChangedAt.IsZero(), "new users start counting password age at creation")
+
+	require.NoError(t, repo.RequirePasswordChange(ctx, created.ID))
+	require.NoError(t, repo.RequirePasswordChange(ctx, created.ID), "requiring twice is not an error")
+	found, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.True(t, found.MustChangePassword)
+
+	changedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, repo.SetPassword(ctx, created.ID, "y", changedAt))
+	found, err = repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "y", found.Password)
+	assert.True(t, changedAt.Equal(found.PasswordChangedAt))
+	assert.False(t, found.MustChangePassword)
+
+	assert.ErrorIs(t, repo.RequirePasswordChange(ctx, created.ID+1), ErrNotFound)
+}
+
+func TestFindByEmailIgnoresCase(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := New(db)
+	ctx := context.Background()
 
-	users, err := repo.FindAll()
+	created, err := repo.Create(ctx, entity.User{Name: "Alice", Email: "Alice@Example.com", Password: "x"})
 	assert.NoError(t, err)
-	assert.Len(t, users, 1)
-	assert.Equal(t, "Test User", users[0].Name)
-}
\ No newline at end of file
+
+	found, err := repo.FindByEmail(ctx, "alice@example.COM")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, found.ID)
+}
+
+func TestReadsGoToReplicaUntilRequestWrites(t *testing.T) {
+	primary, replica := dbtest.OpenWithReplica(t)
+	repo := New(primary)
+	assert.NoError(t, replica.Create(&entity.User{Name: "Replica", Email: "replica@example.com", Password: "x"}).Error)
+
+	ctx := dbrouting.Track(context.Background())
+
+	users, err := repo.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1, "reads before any write should use the replica")
+
+	_, err = repo.Create(ctx, entity.User{Name: "Primary", Email: "primary@example.com", Password: "x"})
+	assert.NoError(t, err)
+
+	found, err := repo.FindByEmail(ctx, "primary@example.com")
+	assert.NoError(t, err, "reads after a write in the same request should use the primary")
+	assert.Equal(t, "Primary", found.Name)
+
+	_, err = repo.FindByEmail(context.Background(), "primary@example.com")
+	assert.ErrorIs(t, err, ErrNotFound, "other requests still read from the lagging replica")
+
+	found, err = repo.FindByEmail(dbrouting.WithPrimary(context.Background()), "primary@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Primary", found.Name)
+}
+
+func TestCreateDuplicateEmailIgnoresCase(t *testing.T) {
+	db := dbtest.Open(t)
+	repo := New(db)
+	ctx := context.Background()
+
+	_, err := repo.Create(ctx, entity.User{Name: "First", Email: "dup@example.com", Password: "x"})
+	assert.NoError(t, err)
+
+	_, err = repo.Create(ctx, entity.User{Name: "Second", Email: "Dup@Example.com", Password: "x"})
+	assert.ErrorIs(t, err, ErrDuplicateEmail)
+}
+
+func TestScanEmailsAndApplyChanges(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+	// จำลองข้อมูลเก่าที่ถูกบันทึกก่อนมี unique index แบบไม่สนตัวพิมพ์
+	require.NoError(t, db.Exec("DROP INDEX uni_users_email_ci").Error)
+	for _, email := range []string{"dup@example.com", "DUP@example.com", "Carol@Example.com", "ok@example.com", "broken"} {
+		require.NoError(t, db.Create(&entity.User{Name: "u", Email: email, Password: "x"}).Error)
+	}
+
+	report, err := ScanEmails(ctx, db, emailutil.Normalizer{})
+	require.NoError(t, err)
+	require.Len(t, report.Collisions, 1)
+	assert.Equal(t, "dup@example.com", report.Collisions[0].Key)
+	assert.Len(t, report.Collisions[0].Users, 2)
+	assert.Equal(t, []EmailChange{{ID: 3, From: "Carol@Example.com", To: "carol@example.com"}}, report.Changes)
+	assert.Equal(t, []EmailRow{{ID: 5, Email: "broken"}}, report.Invalid)
+
+	require.NoError(t, ApplyEmailChanges(ctx, db, report.Changes))
+	_, err = New(db).FindByEmail(ctx, "carol@example.com")
+	assert.NoError(t, err)
+
+	report, err = ScanEmails(ctx, db, emailutil.Normalizer{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Changes)
+}
@@ -1,9 +1,20 @@
 package user
 
-import "github.com/ipxsandbox/internal/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+)
 
 type Repository interface {
-    FindAll() ([]entity.User, error)
-    Create(user entity.User) (entity.User, error)
-    FindByEmail(email string) (entity.User, error)
-}
\ No newline at end of file
+	FindAll(ctx context.Context) ([]entity.User, error)
+	Create(ctx context.Context, user entity.User) (entity.User, error)
+	FindByID(ctx context.Context, id uint) (entity.User, error)
+	FindByEmail(ctx context.Context, email string) (entity.User, error)
+	// UpdatePassword เปลี่ยนแค่ hash เช่นตอน rehash โดยไม่นับว่า user เปลี่ยนรหัสผ่าน
+	UpdatePassword(ctx context.Context, id uint, hashedPassword string) error
+	// SetPassword บันทึกรหัสผ่านที่ user เปลี่ยนเอง ตั้งเวลาที่เปลี่ยนและล้างการบังคับเปลี่ยน
+	SetPassword(ctx context.Context, id uint, hashedPassword string, changedAt time.Time) error
+	RequirePasswordChange(ctx context.Context, id uint) error
+}
@@ -1,31 +1,80 @@
 package user
 
 import (
-    "gorm.io/gorm"
-    "github.com/ipxsandbox/internal/entity"
+	"context"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+	"gorm.io/gorm"
 )
 
 type gormRepository struct {
-    db *gorm.DB
+	db *gorm.DB
 }
 
 func New(db *gorm.DB) Repository {
-    return &gormRepository{db: db}
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) FindAll(ctx context.Context) ([]entity.User, error) {
+	var users []entity.User
+	err := dbrouting.Reader(ctx, r.db).Find(&users).Error
+	return users, translateError(err)
+}
+
+func (r *gormRepository) Create(ctx context.Context, user entity.User) (entity.User, error) {
+	err := dbrouting.Writer(ctx, r.db).Create(&user).Error
+	return user, translateError(err)
+}
+
+func (r *gormRepository) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	var user entity.User
+	err := dbrouting.Reader(ctx, r.db).First(&user, id).Error
+	return user, translateError(err)
+}
+
+func (r *gormRepository) FindByEmail(ctx context.Context, email string) (entity.User, error) {
+	var user entity.User
+	err := dbrouting.Reader(ctx, r.db).Where(emailEquals(r.db), email).First(&user).Error
+	return user, translateError(err)
 }
 
-func (r *gormRepository) FindAll() ([]entity.User, error) {
-    var users []entity.User
-    err := r.db.Find(&users).Error
-    return users, err
+func (r *gormRepository) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
+	err := dbrouting.Writer(ctx, r.db).Model(&entity.User{}).Where("id = ?", id).Update("password", hashedPassword).Error
+	return translateError(err)
 }
 
-func (r *gormRepository) Create(user entity.User) (entity.User, error) {
-    err := r.db.Create(&user).Error
-    return user, err
+func (r *gormRepository) SetPassword(ctx context.Context, id uint, hashedPassword string, changedAt time.Time) error {
+	err := dbrouting.Writer(ctx, r.db).Model(&entity.User{}).Where("id = ?", id).Updates(map[string]any{
+		"password":             hashedPassword,
+		"password_changed_at":  changedAt,
+		"must_change_password": false,
+	}).Error
+	return translateError(err)
 }
 
-func (r *gormRepository) FindByEmail(email string) (entity.User, error) {
-    var user entity.User
-    err := r.db.Where("email = ?", email).First(&user).Error
-    return user, err
-}
\ No newline at end of file
+func (r *gormRepository) RequirePasswordChange(ctx context.Context, id uint) error {
+	res := dbrouting.Writer(ctx, r.db).Model(&entity.User{}).Where("id = ?", id).Update("must_change_password", true)
+	if res.Error != nil {
+		return translateError(res.Error)
+	}
+	// MySQL นับเฉพาะแถวที่ค่าเปลี่ยนจริง จึงต้องตรวจว่ามี user อยู่เมื่อไม่มีแถวถูกแก้
+	if res.RowsAffected == 0 {
+		_, err := r.FindByID(dbrouting.WithPrimary(ctx), id)
+		return err
+	}
+	return nil
+}
+
+// emailEquals คืนเงื่อนไขเทียบ email แบบไม่สนตัวพิมพ์เล็กใหญ่ตาม dialect
+func emailEquals(db *gorm.DB) string {
+	switch db.Dialector.Name() {
+	case "mysql":
+		// collation ของ column เป็น _ci อยู่แล้ว และยังใช้ unique index ได้
+		return "email = ?"
+	case "sqlite":
+		return "email = ? COLLATE NOCASE"
+	}
+	return "LOWER(email) = LOWER(?)"
+}
@@ -1,30 +1,89 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/ipxsandbox/internal/audit"
+	"github.com/ipxsandbox/internal/entity"
 	"github.com/ipxsandbox/internal/handler"
 	"github.com/ipxsandbox/internal/middleware"
+	"github.com/ipxsandbox/internal/pkg/cookieutil"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
+	"github.com/ipxsandbox/internal/pkg/health"
+	"github.com/ipxsandbox/internal/pkg/jwtutil"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	auditRepository "github.com/ipxsandbox/internal/repository/audit"
+	passwordHistoryRepository "github.com/ipxsandbox/internal/repository/passwordhistory"
 	"github.com/ipxsandbox/internal/repository/user"
+	auditUsecase "github.com/ipxsandbox/internal/usecase/audit"
 	authUsecase "github.com/ipxsandbox/internal/usecase/auth_usercase"
 	userUsecase "github.com/ipxsandbox/internal/usecase/user"
 )
 
-func InitRoutes(r *gin.Engine, db *gorm.DB) {
-	userRepo := user.New(db)
-	authUC := authUsecase.NewAuthUsecase(userRepo)
-	userUC := userUsecase.NewUserUsecase(userRepo)
+type Dependencies struct {
+	DB       *gorm.DB
+	Hasher   hashutil.PasswordHasher
+	JWT      *jwtutil.Manager
+	Cookies  *cookieutil.Policy
+	Email    emailutil.Normalizer
+	Password passwordpolicy.Policy
+	// PasswordMaxAge คืออายุสูงสุดของรหัสผ่านแยกตาม role
+	PasswordMaxAge map[string]time.Duration
+	AuthOpts       handler.AuthOptions
+	// AuditSinks คือปลายทางเพิ่มเติม นอกจากตาราง audit_events ที่เขียนเสมอ
+	AuditSinks []audit.Sink
+	Health     *health.Registry
+}
+
+func InitRoutes(r *gin.Engine, deps Dependencies) error {
+	userRepo := user.New(deps.DB)
+	auditRepo := auditRepository.New(deps.DB)
+	historyRepo := passwordHistoryRepository.New(deps.DB)
+	recorder := audit.NewRecorder(append([]audit.Sink{audit.NewGormSink(auditRepo)}, deps.AuditSinks...)...)
+
+	authUC, err := authUsecase.NewAuthUsecase(userRepo, deps.Hasher, deps.JWT, deps.Email, authUsecase.PasswordOptions{
+		History:      historyRepo,
+		HistoryDepth: deps.Password.HistoryDepth,
+		MaxAge:       deps.PasswordMaxAge,
+	})
+	if err != nil {
+		return err
+	}
+	userUC := userUsecase.NewUserUsecase(userRepo, deps.Hasher, deps.Email)
+	auditUC := auditUsecase.NewAuditUsecase(auditRepo)
 
-	authHandler := handler.NewAuthHandler(authUC)
-	userHandler := handler.NewUserHandler(userUC)
+	validate := handler.NewValidator(userRepo, deps.Password)
+	authHandler := handler.NewAuthHandler(authUC, recorder, deps.Cookies, validate, deps.AuthOpts)
+	userHandler := handler.NewUserHandler(userUC, recorder, validate)
+	auditHandler := handler.NewAuditHandler(auditUC)
+	healthHandler := handler.NewHealthHandler(deps.Health)
+	passwordPolicyHandler := handler.NewPasswordPolicyHandler(deps.Password)
 
+	// /register และ /login ยังไม่มี session ให้ปลอมแปลง จึงไม่ต้องมี CSRF token
+	csrf := middleware.CSRF(deps.Cookies)
+
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	r.GET("/password-policy", passwordPolicyHandler.Get)
 	r.POST("/register", authHandler.Register)
 	r.POST("/login", authHandler.Login)
-	r.POST("/refresh-token", authHandler.RefreshToken)
+	r.POST("/refresh-token", csrf, authHandler.RefreshToken)
 
 	auth := r.Group("/")
-	auth.Use(middleware.JWTAuthMiddleware())
+	// user ที่ต้องเปลี่ยนรหัสผ่านได้ token ที่เรียกได้แค่ route นี้
+	auth.Use(middleware.JWTAuthMiddleware(deps.JWT, deps.Cookies, "POST /change-password"), csrf)
+	auth.POST("/change-password", authHandler.ChangePassword)
 	auth.GET("/users", userHandler.GetUsers)
 	auth.POST("/users", userHandler.CreateUser)
-}
\ No newline at end of file
+
+	admin := auth.Group("/admin")
+	admin.Use(middleware.RequireRole(entity.RoleAdmin))
+	admin.GET("/audit", auditHandler.ListEvents)
+	admin.POST("/users/:id/require-password-change", userHandler.RequirePasswordChange)
+	return nil
+}
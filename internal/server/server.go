@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	// ShutdownDelay คือเวลาที่ยังรับ request ต่อหลังได้รับสัญญาณปิด
+	// เพื่อให้ load balancer เห็นว่า readiness ล้มเหลวและเลิกส่ง traffic มาก่อน
+	ShutdownDelay time.Duration
+	TLSCertFile   string
+	TLSKeyFile    string
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type Server struct {
+	http            *http.Server
+	certFile        string
+	keyFile         string
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	beforeShutdown  []func()
+	hooks           []hook
+}
+
+func New(cfg Config, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		certFile:        cfg.TLSCertFile,
+		keyFile:         cfg.TLSKeyFile,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		shutdownDelay:   cfg.ShutdownDelay,
+	}
+}
+
+// BeforeShutdown ลงทะเบียนฟังก์ชันที่เรียกทันทีเมื่อเริ่ม shutdown ก่อนหยุดรับ request
+func (s *Server) BeforeShutdown(fn func()) {
+	s.beforeShutdown = append(s.beforeShutdown, fn)
+}
+
+// OnShutdown ลงทะเบียนงานที่ต้องทำหลังปิดรับ request และรอ request ที่ค้างอยู่เสร็จแล้ว
+// hook จะถูกเรียกตามลำดับที่ลงทะเบียน เช่น ปิด DB ก่อนแล้วค่อยปิด Redis
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.hooks = append(s.hooks, hook{name: name, fn: fn})
+}
+
+// Run เปิดรับ request จนกว่า ctx จะถูกยกเลิก (เช่นได้รับ SIGTERM) แล้วจึง shutdown
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server listening", slog.String("addr", ln.Addr().String()), slog.Bool("tls", s.tlsEnabled()))
+		if s.tlsEnabled() {
+			errCh <- s.http.ServeTLS(ln, s.certFile, s.keyFile)
+		} else {
+			errCh <- s.http.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.runHooks(context.Background())
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	return s.Shutdown()
+}
+
+// Shutdown หยุดรับ connection ใหม่ รอ request ที่ค้างอยู่ไม่เกิน ShutdownTimeout แล้วเรียก hook
+func (s *Server) Shutdown() error {
+	slog.Info("Shutting down HTTP server", slog.Duration("delay", s.shutdownDelay), slog.Duration("timeout", s.shutdownTimeout))
+
+	for _, fn := range s.beforeShutdown {
+		fn()
+	}
+	if s.shutdownDelay > 0 {
+		time.Sleep(s.shutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	err := s.http.Shutdown(ctx)
+	if err != nil {
+		slog.Error("HTTP server did not drain in time", slog.Any("error", err))
+	}
+
+	// hook ได้เวลาของตัวเอง ไม่ต้องแย่งกับเวลาที่ใช้รอ request
+	hookCtx, hookCancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer hookCancel()
+
+	if hookErr := s.runHooks(hookCtx); hookErr != nil && err == nil {
+		err = hookErr
+	}
+	return err
+}
+
+func (s *Server) runHooks(ctx context.Context) error {
+	var errs []error
+	for _, h := range s.hooks {
+		if err := h.fn(ctx); err != nil {
+			slog.Error("Shutdown hook failed", slog.String("hook", h.name), slog.Any("error", err))
+			errs = append(errs, err)
+			continue
+		}
+		slog.Info("Shutdown hook completed", slog.String("hook", h.name))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) tlsEnabled() bool {
+	return s.certFile != "" && s.keyFile != ""
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"math"
+
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/entity"
+	auditRepository "github.com/ipxsandbox/internal/repository/audit"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+var ErrPageTooLarge = apperror.Validation("invalid query parameters", apperror.FieldError{Field: "page", Code: "max", Message: "page is too large"})
+
+type usecase struct {
+	repo auditRepository.Repository
+}
+
+func NewAuditUsecase(repo auditRepository.Repository) Usecase {
+	return &usecase{repo: repo}
+}
+
+func (u *usecase) List(ctx context.Context, filter auditRepository.Filter, page, pageSize int) (Page, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	// page ที่ใหญ่เกินจะทำให้ offset ล้น int กลายเป็นค่าติดลบ
+	if page > math.MaxInt/pageSize {
+		return Page{}, ErrPageTooLarge
+	}
+
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	events, total, err := u.repo.List(ctx, filter)
+	if err != nil {
+		return Page{}, err
+	}
+	if events == nil {
+		events = []entity.AuditEvent{}
+	}
+
+	return Page{Items: events, Page: page, PageSize: pageSize, Total: total}, nil
+}
@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/entity"
+	auditRepository "github.com/ipxsandbox/internal/repository/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *mockAuditRepo) Create(ctx context.Context, event entity.AuditEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *mockAuditRepo) List(ctx context.Context, filter auditRepository.Filter) ([]entity.AuditEvent, int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]entity.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+func TestList_ClampsPaging(t *testing.T) {
+	repo := new(mockAuditRepo)
+	repo.On("List", auditRepository.Filter{Limit: MaxPageSize, Offset: MaxPageSize}).Return([]entity.AuditEvent(nil), int64(0), nil)
+
+	page, err := NewAuditUsecase(repo).List(context.Background(), auditRepository.Filter{}, 2, 1000)
+
+	require.NoError(t, err)
+	assert.Equal(t, MaxPageSize, page.PageSize)
+	assert.NotNil(t, page.Items)
+}
+
+func TestList_RejectsOffsetOverflow(t *testing.T) {
+	repo := new(mockAuditRepo)
+
+	_, err := NewAuditUsecase(repo).List(context.Background(), auditRepository.Filter{}, math.MaxInt, 50)
+
+	assert.ErrorIs(t, err, ErrPageTooLarge)
+	assert.Equal(t, apperror.KindValidation, apperror.KindOf(err))
+	repo.AssertNotCalled(t, "List", mock.Anything)
+}
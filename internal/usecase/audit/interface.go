@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/ipxsandbox/internal/entity"
+	auditRepository "github.com/ipxsandbox/internal/repository/audit"
+)
+
+type Page struct {
+	Items    []entity.AuditEvent `json:"items"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int64               `json:"total"`
+}
+
+type Usecase interface {
+	List(ctx context.Context, filter auditRepository.Filter, page, pageSize int) (Page, error)
+}
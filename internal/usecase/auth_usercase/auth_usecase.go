@@ -1,87 +1,295 @@
 package auth_usercase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/ipxsandbox/internal/apperror"
 	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/dbrouting"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
 	"github.com/ipxsandbox/internal/pkg/jwtutil"
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"github.com/ipxsandbox/internal/pkg/tracing"
+	passwordHistoryRepository "github.com/ipxsandbox/internal/repository/passwordhistory"
 	userRepository "github.com/ipxsandbox/internal/repository/user"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthUsecaseInterface interface {
-	Register(user entity.User) (entity.UserResponse, error)
-	Login(email string, password string) (accessToken string, refreshToken string, err error)
-	RefreshAccessToken(refreshToken string) (string, error)
+	Register(ctx context.Context, user entity.User) (entity.UserResponse, error)
+	Login(ctx context.Context, email string, password string) (LoginResult, error)
+	RefreshAccessToken(ctx context.Context, refreshToken string) (string, error)
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error
 }
 
+// LoginResult เมื่อ PasswordChangeRequired เป็น true AccessToken ใช้ได้แค่เปลี่ยนรหัสผ่านและไม่มี RefreshToken
+type LoginResult struct {
+	AccessToken            string
+	RefreshToken           string
+	PasswordChangeRequired bool
+}
+
+// PasswordOptions กำหนดการเก็บรหัสผ่านเดิมและอายุของรหัสผ่าน
+type PasswordOptions struct {
+	// History เป็น nil ได้เมื่อ HistoryDepth เป็น 0 ซึ่งจะไม่เก็บและไม่ตรวจรหัสผ่านเดิม
+	History      passwordHistoryRepository.Repository
+	HistoryDepth int
+	// MaxAge คืออายุสูงสุดของรหัสผ่านแยกตาม role ถ้า role ไม่อยู่ในนี้จะไม่บังคับเปลี่ยนตามรอบ
+	MaxAge map[string]time.Duration
+}
+
+var (
+	ErrInvalidCredentials     = apperror.Unauthorized("invalid credentials")
+	ErrEmailTaken             = apperror.Conflict("email already registered")
+	ErrInvalidToken           = apperror.Unauthorized("invalid or expired refresh token")
+	ErrPasswordChangeRequired = apperror.Unauthorized("password change required, log in again")
+	ErrInvalidEmail           = apperror.Validation("validation failed", apperror.FieldError{Field: "email", Code: "email", Message: "invalid email address"})
+	ErrWrongPassword          = apperror.Validation("validation failed", apperror.FieldError{Field: "current_password", Code: "invalid", Message: "current password is incorrect"})
+	ErrPasswordReused         = apperror.Validation("password was used recently", apperror.FieldError{Field: "new_password", Code: "password_reused", Message: "new password must not match a recently used password"})
+)
+
 type authUsecase struct {
-	userRepo userRepository.Repository
+	userRepo  userRepository.Repository
+	hasher    hashutil.PasswordHasher
+	jwt       *jwtutil.Manager
+	email     emailutil.Normalizer
+	password  PasswordOptions
+	dummyHash string
+	now       func() time.Time
 }
 
-func NewAuthUsecase(repo userRepository.Repository) AuthUsecaseInterface {
-	return &authUsecase{userRepo: repo}
+func NewAuthUsecase(repo userRepository.Repository, hasher hashutil.PasswordHasher, jwt *jwtutil.Manager, email emailutil.Normalizer, password PasswordOptions) (AuthUsecaseInterface, error) {
+	// hash หลอกที่ใช้ parameter ชุดเดียวกับ user จริง เพื่อให้ login ด้วย email ที่ไม่มีอยู่
+	// ใช้เวลาเท่ากับ email ที่มีอยู่ ป้องกันการเดา email จากเวลาตอบกลับ
+	dummyHash, err := hasher.Hash("dummy-password-for-timing-equalization")
+	if err != nil {
+		return nil, fmt.Errorf("create dummy password hash: %w", err)
+	}
+	return &authUsecase{userRepo: repo, hasher: hasher, jwt: jwt, email: email, password: password, dummyHash: dummyHash, now: time.Now}, nil
 }
 
-func (uc *authUsecase) Register(user entity.User) (entity.UserResponse, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+func (uc *authUsecase) Register(ctx context.Context, user entity.User) (_ entity.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.Register")
+	defer tracing.End(span, &err)
+
+	if user.Email, err = uc.email.Normalize(user.Email); err != nil {
+		return entity.UserResponse{}, ErrInvalidEmail.WithCause(err)
+	}
+
+	hashed, err := uc.hash(ctx, user.Password)
 	if err != nil {
 		return entity.UserResponse{}, err
 	}
-	user.Password = string(hashed)
+	user.Password = hashed
+	// การสมัครด้วยตัวเองได้ role user เสมอ
+	user.Role = entity.RoleUser
 
-	createdUser, err := uc.userRepo.Create(user)
+	createdUser, err := uc.userRepo.Create(ctx, user)
+	if errors.Is(err, userRepository.ErrDuplicateEmail) {
+		return entity.UserResponse{}, ErrEmailTaken
+	}
 	if err != nil {
 		return entity.UserResponse{}, err
 	}
 
-	return entity.UserResponse{
-		ID:    createdUser.ID,
-		Name:  createdUser.Name,
-		Email: createdUser.Email,
-	}, nil
+	return createdUser.Response(), nil
+}
+
+func (uc *authUsecase) Login(ctx context.Context, email, password string) (_ LoginResult, err error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.Login")
+	defer tracing.End(span, &err)
+
+	user, err := uc.findForLogin(ctx, email)
+	if errors.Is(err, userRepository.ErrNotFound) {
+		_ = uc.verify(ctx, password, uc.dummyHash)
+		return LoginResult{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	err = uc.verify(ctx, password, user.Password)
+	if errors.Is(err, hashutil.ErrMismatch) {
+		return LoginResult{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	// hash เดิมใช้ algorithm หรือ cost ที่ล้าสมัย ให้ hash ใหม่ด้วยค่าปัจจุบันแล้วบันทึกทับ
+	if uc.hasher.NeedsRehash(user.Password) {
+		uc.rehash(ctx, user.ID, password)
+	}
+
+	if uc.passwordChangeRequired(user) {
+		accessToken, err := uc.jwt.GeneratePasswordChangeToken(user.ID, user.Role, user.Locale)
+		if err != nil {
+			return LoginResult{}, err
+		}
+		return LoginResult{AccessToken: accessToken, PasswordChangeRequired: true}, nil
+	}
+
+	accessToken, refreshToken, err := uc.jwt.GenerateTokens(user.ID, user.Role, user.Locale)
+	if err != nil {
+		return LoginResult{}, err
+	}
+
+	return LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// passwordChangeRequired เป็นจริงเมื่อ admin สั่งให้เปลี่ยนหรือรหัสผ่านเก่ากว่าอายุสูงสุดของ role
+func (uc *authUsecase) passwordChangeRequired(user entity.User) bool {
+	if user.MustChangePassword {
+		return true
+	}
+	maxAge, ok := uc.password.MaxAge[user.Role]
+	return ok && maxAge > 0 && uc.now().Sub(user.PasswordChangedAt) > maxAge
+}
+
+// ChangePassword อ่าน user และรหัสผ่านเดิมจาก primary เพราะ replica อาจยังไม่เห็นการเปลี่ยนครั้งล่าสุด
+func (uc *authUsecase) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) (err error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.ChangePassword")
+	defer tracing.End(span, &err)
+
+	ctx = dbrouting.WithPrimary(ctx)
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = uc.verify(ctx, currentPassword, user.Password)
+	if errors.Is(err, hashutil.ErrMismatch) {
+		return ErrWrongPassword
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := uc.checkHistory(ctx, user.ID, newPassword); err != nil {
+		return err
+	}
+
+	hashed, err := uc.hash(ctx, newPassword)
+	if err != nil {
+		return err
+	}
+	if err := uc.remember(ctx, user); err != nil {
+		return err
+	}
+	return uc.userRepo.SetPassword(ctx, user.ID, hashed, uc.now().UTC())
 }
 
-func (uc *authUsecase) Login(email, password string) (string, string, error) {
-	user, err := uc.userRepo.FindByEmail(email)
+// checkHistory เทียบรหัสผ่านใหม่กับ hash เดิมทีละตัวด้วย hasher ที่ตรวจได้ทุก algorithm
+// จำนวนที่ต้องเทียบถูกจำกัดด้วย passwordpolicy.MaxHistoryDepth hash ที่อ่านไม่ได้จะถูกข้าม
+// เพื่อไม่ให้ user เปลี่ยนรหัสผ่านไม่ได้เลย
+func (uc *authUsecase) checkHistory(ctx context.Context, userID uint, password string) error {
+	if uc.password.HistoryDepth <= 0 {
+		return nil
+	}
+	entries, err := uc.password.History.Recent(ctx, userID, uc.password.HistoryDepth)
 	if err != nil {
-		return "", "", err
+		return err
+	}
+	for _, entry := range entries {
+		err := uc.verify(ctx, password, entry.Password)
+		if err == nil {
+			return ErrPasswordReused
+		}
+		if !errors.Is(err, hashutil.ErrMismatch) {
+			logger.FromContext(ctx).Warn("Failed to verify password history entry",
+				slog.Uint64("user_id", uint64(userID)), slog.Any("error", err))
+		}
+	}
+	return nil
+}
+
+// remember เก็บ hash ปัจจุบันก่อนเปลี่ยน ถ้าเปลี่ยนไม่สำเร็จ history จะมี hash ของรหัสผ่านที่ยังใช้อยู่
+// ซึ่งไม่เป็นปัญหาเพราะรหัสผ่านใหม่ต้องไม่ตรงกับรหัสผ่านปัจจุบันอยู่แล้ว
+// รายการที่เกินจำนวนที่ต้องเก็บจะถูกลบโดย cleanup job
+func (uc *authUsecase) remember(ctx context.Context, user entity.User) error {
+	if uc.password.HistoryDepth <= 0 {
+		return nil
 	}
+	return uc.password.History.Create(ctx, entity.PasswordHistory{UserID: user.ID, Password: user.Password, CreatedAt: uc.now().UTC()})
+}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+// findForLogin ตอบ ErrNotFound เมื่อ email ไม่ถูกรูปแบบ เพื่อให้ใช้เวลาเท่ากับ email ที่ไม่มีอยู่
+func (uc *authUsecase) findForLogin(ctx context.Context, email string) (entity.User, error) {
+	normalized, err := uc.email.Normalize(email)
 	if err != nil {
-		return "", "", err
+		return entity.User{}, userRepository.ErrNotFound.WithCause(err)
 	}
+	return uc.userRepo.FindByEmail(ctx, normalized)
+}
 
-	accessToken, refreshToken, err := jwtutil.GenerateTokens(user.ID)
+// rehash ไม่ทำให้ login ล้มเหลว ถ้าบันทึกไม่สำเร็จจะลองใหม่ใน login ครั้งถัดไป
+func (uc *authUsecase) rehash(ctx context.Context, userID uint, password string) {
+	log := logger.FromContext(ctx)
+	hashed, err := uc.hash(ctx, password)
 	if err != nil {
-		return "", "", err
+		log.Warn("Failed to rehash password", slog.Uint64("user_id", uint64(userID)), slog.Any("error", err))
+		return
 	}
+	if err := uc.userRepo.UpdatePassword(ctx, userID, hashed); err != nil {
+		log.Warn("Failed to persist rehashed password", slog.Uint64("user_id", uint64(userID)), slog.Any("error", err))
+	}
+}
 
-	return accessToken, refreshToken, nil
+// hash และ verify แยก span ไว้ เพราะเป็นส่วนที่กินเวลามากที่สุดของ register/login
+func (uc *authUsecase) hash(ctx context.Context, password string) (_ string, err error) {
+	_, span := tracing.Start(ctx, "password.hash")
+	defer tracing.End(span, &err)
+	return uc.hasher.Hash(password)
 }
 
-func (uc *authUsecase) RefreshAccessToken(refreshToken string) (string, error) {
-    token, err := jwtutil.ParseToken(refreshToken)
-    if err != nil || !token.Valid {
-        return "", errors.New("invalid refresh token")
-    }
+func (uc *authUsecase) verify(ctx context.Context, password, encoded string) error {
+	_, span := tracing.Start(ctx, "password.verify")
+	defer span.End()
+	// mismatch เป็นผลปกติ ไม่นับเป็น error ของ span
+	return uc.hasher.Verify(password, encoded)
+}
 
-    claims, ok := token.Claims.(jwt.MapClaims)
-    if !ok || claims["sub"] == nil {
-        return "", errors.New("invalid token claims")
-    }
+// RefreshAccessToken อ่าน user อีกครั้งเพื่อให้การบังคับเปลี่ยนรหัสผ่านมีผลกับ session ที่ login อยู่แล้ว
+// โดยตอบ ErrPasswordChangeRequired ให้ client login ใหม่เพื่อรับ token สำหรับเปลี่ยนรหัสผ่าน
+func (uc *authUsecase) RefreshAccessToken(ctx context.Context, refreshToken string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.RefreshAccessToken")
+	defer tracing.End(span, &err)
 
-    userIDFloat, ok := claims["sub"].(float64)
-    if !ok {
-        return "", errors.New("invalid user ID")
-    }
+	token, err := uc.jwt.ParseToken(refreshToken)
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken.WithCause(err)
+	}
 
-    newAccessToken, _, err := jwtutil.GenerateTokens(uint(userIDFloat))
-    if err != nil {
-        return "", err
-    }
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["sub"] == nil {
+		return "", ErrInvalidToken.WithCause(errors.New("invalid token claims"))
+	}
 
-    return newAccessToken, nil
-}
\ No newline at end of file
+	userIDFloat, ok := claims["sub"].(float64)
+	if !ok {
+		return "", ErrInvalidToken.WithCause(errors.New("invalid user ID"))
+	}
+	// access token และ token สำหรับเปลี่ยนรหัสผ่านใช้ขอ token ใหม่ไม่ได้
+	if claims[jwtutil.ClaimType] != jwtutil.TypeRefresh {
+		return "", ErrInvalidToken.WithCause(errors.New("not a refresh token"))
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, uint(userIDFloat))
+	if errors.Is(err, userRepository.ErrNotFound) {
+		return "", ErrInvalidToken.WithCause(err)
+	}
+	if err != nil {
+		return "", err
+	}
+	if uc.passwordChangeRequired(user) {
+		return "", ErrPasswordChangeRequired
+	}
+
+	// ใช้ role และ locale ล่าสุดจากฐานข้อมูล ไม่ใช่ค่าใน refresh token ที่อาจเก่าไปแล้ว
+	// user ที่ถูกลดสิทธิ์จึงไม่ได้ token ของ role เดิมกลับมา
+	return uc.jwt.GenerateAccessToken(user.ID, user.Role, user.Locale)
+}
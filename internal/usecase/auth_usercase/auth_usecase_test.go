@@ -0,0 +1,378 @@
+package auth_usercase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
+	"github.com/ipxsandbox/internal/pkg/jwtutil"
+	userRepository "github.com/ipxsandbox/internal/repository/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock Repository
+type mockUserRepo struct {
+	mock.Mock
+}
+
+func (m *mockUserRepo) FindAll(ctx context.Context) ([]entity.User, error) {
+	args := m.Called()
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
+func (m *mockUserRepo) Create(ctx context.Context, user entity.User) (entity.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *mockUserRepo) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *mockUserRepo) FindByEmail(ctx context.Context, email string) (entity.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *mockUserRepo) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
+	args := m.Called(id, hashedPassword)
+	return args.Error(0)
+}
+
+type mockHistoryRepo struct {
+	mock.Mock
+}
+
+func (m *mockHistoryRepo) Create(ctx context.Context, entry entity.PasswordHistory) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *mockHistoryRepo) Recent(ctx context.Context, userID uint, limit int) ([]entity.PasswordHistory, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]entity.PasswordHistory), args.Error(1)
+}
+
+func (m *mockHistoryRepo) Prune(ctx context.Context, keep int) (int64, error) {
+	args := m.Called(keep)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockUserRepo) SetPassword(ctx context.Context, id uint, hashedPassword string, changedAt time.Time) error {
+	args := m.Called(id, hashedPassword, changedAt)
+	return args.Error(0)
+}
+
+func (m *mockUserRepo) RequirePasswordChange(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+var testJWT = jwtutil.New("test-secret-0123456789abcdefghijklmnop", 15*time.Minute, time.Hour)
+
+func newTestHasher(t *testing.T, algorithm string) hashutil.PasswordHasher {
+	h, err := hashutil.New(hashutil.Config{
+		Algorithm:  algorithm,
+		BcryptCost: 4,
+		Argon2:     hashutil.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	})
+	require.NoError(t, err)
+	return h
+}
+
+func newTestUsecase(t *testing.T, repo userRepository.Repository, hasher hashutil.PasswordHasher, jwt *jwtutil.Manager, email emailutil.Normalizer, password PasswordOptions) AuthUsecaseInterface {
+	uc, err := NewAuthUsecase(repo, hasher, jwt, email, password)
+	require.NoError(t, err)
+	return uc
+}
+
+type failingHasher struct{ hashutil.PasswordHasher }
+
+func (failingHasher) Hash(string) (string, error) { return "", errors.New("hash failed") }
+
+func TestNewAuthUsecase_DummyHashError(t *testing.T) {
+	_, err := NewAuthUsecase(new(mockUserRepo), failingHasher{}, testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	assert.ErrorContains(t, err, "hash failed")
+}
+
+func TestLogin_RehashesOutdatedHash(t *testing.T) {
+	bcryptHasher := newTestHasher(t, hashutil.AlgorithmBcrypt)
+	oldHash, err := bcryptHasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByEmail", "alice@example.com").
+		Return(entity.User{ID: 1, Email: "alice@example.com", Password: oldHash}, nil)
+	mockRepo.On("UpdatePassword", uint(1), mock.MatchedBy(func(h string) bool {
+		return h != oldHash && len(h) > len("$argon2id$")
+	})).Return(nil)
+
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	result, err := uc.Login(context.Background(), "alice@example.com", "Secret#123")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+	assert.False(t, result.PasswordChangeRequired)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_CurrentHashIsNotRehashed(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByEmail", "alice@example.com").
+		Return(entity.User{ID: 1, Email: "alice@example.com", Password: currentHash}, nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	_, err = uc.Login(context.Background(), "alice@example.com", "Secret#123")
+	assert.NoError(t, err)
+
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByEmail", "alice@example.com").
+		Return(entity.User{ID: 1, Email: "alice@example.com", Password: currentHash}, nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	_, err = uc.Login(context.Background(), "alice@example.com", "Wrong#123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func TestLogin_UnknownEmail(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByEmail", "ghost@example.com").Return(entity.User{}, userRepository.ErrNotFound)
+
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	_, err := uc.Login(context.Background(), "ghost@example.com", "Secret#123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangePassword(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByID", uint(1)).Return(entity.User{ID: 1, Password: currentHash}, nil)
+	mockRepo.On("SetPassword", uint(1), mock.MatchedBy(func(h string) bool {
+		return hasher.Verify("Secret#456", h) == nil
+	}), mock.AnythingOfType("time.Time")).Return(nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	assert.NoError(t, uc.ChangePassword(context.Background(), 1, "Secret#123", "Secret#456"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByID", uint(1)).Return(entity.User{ID: 1, Password: currentHash}, nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	err = uc.ChangePassword(context.Background(), 1, "Wrong#123", "Secret#456")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	mockRepo.AssertNotCalled(t, "SetPassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestChangePassword_RecordsHistory(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+	oldHash, err := hasher.Hash("Secret#000")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByID", uint(1)).Return(entity.User{ID: 1, Password: currentHash}, nil)
+	mockRepo.On("SetPassword", uint(1), mock.Anything, mock.Anything).Return(nil)
+	history := new(mockHistoryRepo)
+	history.On("Recent", uint(1), 3).Return([]entity.PasswordHistory{{UserID: 1, Password: oldHash}}, nil)
+	history.On("Create", mock.MatchedBy(func(e entity.PasswordHistory) bool {
+		return e.UserID == 1 && e.Password == currentHash && !e.CreatedAt.IsZero()
+	})).Return(nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{History: history, HistoryDepth: 3})
+	assert.NoError(t, uc.ChangePassword(context.Background(), 1, "Secret#123", "Secret#456"))
+	mockRepo.AssertExpectations(t)
+	history.AssertExpectations(t)
+}
+
+func TestChangePassword_RejectsReusedPassword(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	// hash เดิมที่ใช้ algorithm อื่นต้องตรวจได้ด้วย
+	bcryptHash, err := newTestHasher(t, hashutil.AlgorithmBcrypt).Hash("Secret#000")
+	require.NoError(t, err)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByID", uint(1)).Return(entity.User{ID: 1, Password: currentHash}, nil)
+	history := new(mockHistoryRepo)
+	history.On("Recent", uint(1), 3).Return([]entity.PasswordHistory{{Password: "not-a-hash"}, {Password: bcryptHash}}, nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{History: history, HistoryDepth: 3})
+	err = uc.ChangePassword(context.Background(), 1, "Secret#123", "Secret#000")
+	assert.ErrorIs(t, err, ErrPasswordReused)
+	mockRepo.AssertNotCalled(t, "SetPassword", mock.Anything, mock.Anything, mock.Anything)
+	history.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLogin_PasswordChangeRequired(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		user     entity.User
+		required bool
+	}{
+		{"forced by admin", entity.User{Role: entity.RoleUser, PasswordChangedAt: now, MustChangePassword: true}, true},
+		{"expired for role", entity.User{Role: entity.RoleAdmin, PasswordChangedAt: now.Add(-91 * 24 * time.Hour)}, true},
+		{"within max age", entity.User{Role: entity.RoleAdmin, PasswordChangedAt: now.Add(-89 * 24 * time.Hour)}, false},
+		{"role without rotation", entity.User{Role: entity.RoleUser, PasswordChangedAt: now.Add(-365 * 24 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			user.ID, user.Email, user.Password = 1, "alice@example.com", currentHash
+			mockRepo := new(mockUserRepo)
+			mockRepo.On("FindByEmail", "alice@example.com").Return(user, nil)
+
+			uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{},
+				PasswordOptions{MaxAge: map[string]time.Duration{entity.RoleAdmin: 90 * 24 * time.Hour}})
+			result, err := uc.Login(context.Background(), "alice@example.com", "Secret#123")
+			require.NoError(t, err)
+			assert.Equal(t, tt.required, result.PasswordChangeRequired)
+			assert.Equal(t, tt.required, result.RefreshToken == "", "restricted logins get no refresh token")
+
+			token, err := testJWT.ParseToken(result.AccessToken)
+			require.NoError(t, err)
+			wantType := jwtutil.TypeAccess
+			if tt.required {
+				wantType = jwtutil.TypePasswordChange
+			}
+			assert.Equal(t, wantType, token.Claims.(jwt.MapClaims)[jwtutil.ClaimType])
+		})
+	}
+}
+
+func TestRefreshAccessToken_PasswordChangeRequired(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByID", uint(1)).Return(entity.User{ID: 1, PasswordChangedAt: time.Now(), MustChangePassword: true}, nil)
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+
+	_, refreshToken, err := testJWT.GenerateTokens(1, entity.RoleUser, "")
+	require.NoError(t, err)
+	_, err = uc.RefreshAccessToken(context.Background(), refreshToken)
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	restricted, err := testJWT.GeneratePasswordChangeToken(1, entity.RoleUser, "")
+	require.NoError(t, err)
+	_, err = uc.RefreshAccessToken(context.Background(), restricted)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRefreshAccessToken_UsesCurrentRoleAndLocale(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByID", uint(1)).Return(entity.User{ID: 1, Role: entity.RoleUser, Locale: "th", PasswordChangedAt: time.Now()}, nil)
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+
+	// refresh token ออกตอนที่ user ยังเป็น admin
+	_, refreshToken, err := testJWT.GenerateTokens(1, entity.RoleAdmin, "en")
+	require.NoError(t, err)
+	access, err := uc.RefreshAccessToken(context.Background(), refreshToken)
+	require.NoError(t, err)
+
+	token, err := testJWT.ParseToken(access)
+	require.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, entity.RoleUser, claims["role"])
+	assert.Equal(t, "th", claims["locale"])
+	assert.Equal(t, jwtutil.TypeAccess, claims[jwtutil.ClaimType])
+}
+
+func TestRefreshAccessToken_RejectsAccessToken(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+
+	access, _, err := testJWT.GenerateTokens(1, entity.RoleUser, "")
+	require.NoError(t, err)
+	_, err = uc.RefreshAccessToken(context.Background(), access)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestRegister_EmailTaken(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("Create", mock.AnythingOfType("entity.User")).Return(entity.User{}, userRepository.ErrDuplicateEmail)
+
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	_, err := uc.Register(context.Background(), entity.User{Name: "Alice", Email: "alice@example.com", Password: "Secret#123"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_NormalizesEmail(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("Create", mock.MatchedBy(func(u entity.User) bool { return u.Email == "alice@example.com" })).
+		Return(entity.User{ID: 1, Email: "alice@example.com"}, nil)
+
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	resp, err := uc.Register(context.Background(), entity.User{Name: "Alice", Email: " Alice@Example.COM ", Password: "Secret#123"})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", resp.Email)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_NormalizesEmail(t *testing.T) {
+	hasher := newTestHasher(t, hashutil.AlgorithmArgon2id)
+	currentHash, err := hasher.Hash("Secret#123")
+	require.NoError(t, err)
+
+	mockRepo := new(mockUserRepo)
+	mockRepo.On("FindByEmail", "alice@example.com").
+		Return(entity.User{ID: 1, Email: "alice@example.com", Password: currentHash}, nil)
+
+	uc := newTestUsecase(t, mockRepo, hasher, testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	_, err = uc.Login(context.Background(), "ALICE@example.com", "Secret#123")
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_MalformedEmailIsInvalidCredentials(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+
+	uc := newTestUsecase(t, mockRepo, newTestHasher(t, hashutil.AlgorithmArgon2id), testJWT, emailutil.Normalizer{}, PasswordOptions{})
+	_, err := uc.Login(context.Background(), "not-an-email", "Secret#123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+}
@@ -0,0 +1,48 @@
+package passwordhistory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	passwordHistoryRepository "github.com/ipxsandbox/internal/repository/passwordhistory"
+)
+
+// Cleanup ลบรหัสผ่านเดิมที่เกินจำนวนที่ policy ต้องใช้ตรวจ ตอนเปลี่ยนรหัสผ่านจะเพิ่มอย่างเดียวไม่ลบ
+// และเมื่อลด HistoryDepth ลงรายการที่เกินจะถูกลบในรอบถัดไป
+// ทุก replica รันพร้อมกันได้เพราะการลบซ้ำไม่มีผลเพิ่ม
+type Cleanup struct {
+	repo     passwordHistoryRepository.Repository
+	keep     int
+	interval time.Duration
+	log      *slog.Logger
+}
+
+func NewCleanup(repo passwordHistoryRepository.Repository, keep int, interval time.Duration, log *slog.Logger) *Cleanup {
+	return &Cleanup{repo: repo, keep: keep, interval: interval, log: log}
+}
+
+func (c *Cleanup) RunOnce(ctx context.Context) (int64, error) {
+	return c.repo.Prune(ctx, c.keep)
+}
+
+// Run ลบครั้งแรกทันทีแล้วทำซ้ำทุก interval จนกว่า ctx จะถูกยกเลิก ถ้าลบไม่สำเร็จจะ log ไว้แล้วรอรอบถัดไป
+func (c *Cleanup) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := c.RunOnce(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			c.log.Error("Failed to prune password history", slog.Any("error", err))
+		case deleted > 0:
+			c.log.Info("Pruned password history", slog.Int64("deleted", deleted), slog.Int("keep", c.keep))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package passwordhistory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepo struct {
+	calls atomic.Int32
+	keep  atomic.Int32
+	err   error
+}
+
+func (f *fakeRepo) Create(context.Context, entity.PasswordHistory) error { return nil }
+
+func (f *fakeRepo) Recent(context.Context, uint, int) ([]entity.PasswordHistory, error) {
+	return nil, nil
+}
+
+func (f *fakeRepo) Prune(_ context.Context, keep int) (int64, error) {
+	f.calls.Add(1)
+	f.keep.Store(int32(keep))
+	return 1, f.err
+}
+
+func TestCleanupRunsUntilCancelled(t *testing.T) {
+	repo := &fakeRepo{err: errors.New("database is down")}
+	c := NewCleanup(repo, 5, time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return repo.calls.Load() >= 3 }, time.Second, time.Millisecond,
+		"a failed run must not stop later runs")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	assert.Equal(t, int32(5), repo.keep.Load())
+}
@@ -1,8 +1,14 @@
 package user
 
-import "github.com/ipxsandbox/internal/entity"
+import (
+	"context"
+
+	"github.com/ipxsandbox/internal/entity"
+)
 
 type Usecase interface {
-    GetAllUsers() ([]entity.User, error)
-    CreateUser(user entity.User) (entity.User, error)
-}
\ No newline at end of file
+	GetAllUsers(ctx context.Context) ([]entity.User, error)
+	CreateUser(ctx context.Context, user entity.User) (entity.User, error)
+	// RequirePasswordChange บังคับให้ user เปลี่ยนรหัสผ่านก่อนใช้งานอื่น มีผลเมื่อ login หรือขอ access token ใหม่ครั้งถัดไป
+	RequirePasswordChange(ctx context.Context, id uint) error
+}
@@ -1,22 +1,42 @@
 package user
 
 import (
+	"context"
+
+	"github.com/ipxsandbox/internal/apperror"
 	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
 	"github.com/ipxsandbox/internal/repository/user"
 )
 
+var ErrInvalidEmail = apperror.Validation("validation failed", apperror.FieldError{Field: "email", Code: "email", Message: "invalid email address"})
+
 type usecase struct {
-	repo user.Repository
+	repo   user.Repository
+	hasher hashutil.PasswordHasher
+	email  emailutil.Normalizer
+}
+
+func NewUserUsecase(repo user.Repository, hasher hashutil.PasswordHasher, email emailutil.Normalizer) Usecase {
+	return &usecase{repo: repo, hasher: hasher, email: email}
 }
 
-func NewUserUsecase(repo user.Repository) Usecase {
-	return &usecase{repo: repo}
+func (u *usecase) GetAllUsers(ctx context.Context) ([]entity.User, error) {
+	return u.repo.FindAll(ctx)
 }
 
-func (u *usecase) GetAllUsers() ([]entity.User, error) {
-	return u.repo.FindAll()
+func (u *usecase) CreateUser(ctx context.Context, user entity.User) (_ entity.User, err error) {
+	if user.Email, err = u.email.Normalize(user.Email); err != nil {
+		return entity.User{}, ErrInvalidEmail.WithCause(err)
+	}
+	// ใช้ hasher ตัวเดียวกับ auth usecase เพื่อให้ user ที่ admin สร้าง login ได้
+	if user.Password, err = u.hasher.Hash(user.Password); err != nil {
+		return entity.User{}, err
+	}
+	return u.repo.Create(ctx, user)
 }
 
-func (u *usecase) CreateUser(user entity.User) (entity.User, error) {
-	return u.repo.Create(user)
+func (u *usecase) RequirePasswordChange(ctx context.Context, id uint) error {
+	return u.repo.RequirePasswordChange(ctx, id)
 }
@@ -1,12 +1,18 @@
 package user
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/emailutil"
+	"github.com/ipxsandbox/internal/pkg/hashutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock Repository
@@ -14,28 +20,62 @@ type mockUserRepo struct {
 	mock.Mock
 }
 
-func (m *mockUserRepo) FindAll() ([]entity.User, error) {
+func (m *mockUserRepo) FindAll(ctx context.Context) ([]entity.User, error) {
 	args := m.Called()
 	return args.Get(0).([]entity.User), args.Error(1)
 }
 
-func (m *mockUserRepo) Create(user entity.User) (entity.User, error) {
+func (m *mockUserRepo) Create(ctx context.Context, user entity.User) (entity.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(entity.User), args.Error(1)
 }
 
-func (m *mockUserRepo) FindByEmail(email string) (entity.User, error) {
+func (m *mockUserRepo) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *mockUserRepo) FindByEmail(ctx context.Context, email string) (entity.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(entity.User), args.Error(1)
 }
 
+func (m *mockUserRepo) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
+	args := m.Called(id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *mockUserRepo) SetPassword(ctx context.Context, id uint, hashedPassword string, changedAt time.Time) error {
+	args := m.Called(id, hashedPassword, changedAt)
+	return args.Error(0)
+}
+
+func (m *mockUserRepo) RequirePasswordChange(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func newTestHasher(t *testing.T) hashutil.PasswordHasher {
+	h, err := hashutil.New(hashutil.Config{
+		Algorithm: hashutil.AlgorithmArgon2id,
+		Argon2:    hashutil.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	})
+	require.NoError(t, err)
+	return h
+}
+
+// withName จับ user ที่ส่งให้ repo โดยไม่สนใจ hash ซึ่งเปลี่ยนทุกครั้งเพราะ salt สุ่ม
+func withName(name, email string) any {
+	return mock.MatchedBy(func(u entity.User) bool { return u.Name == name && u.Email == email })
+}
+
 func TestGetAllUsers(t *testing.T) {
 	mockRepo := new(mockUserRepo)
 	mockUsers := []entity.User{{ID: 1, Name: "Alice", Email: "alice@example.com"}}
 	mockRepo.On("FindAll").Return(mockUsers, nil)
 
-	uc := NewUserUsecase(mockRepo)
-	users, err := uc.GetAllUsers()
+	uc := NewUserUsecase(mockRepo, newTestHasher(t), emailutil.Normalizer{})
+	users, err := uc.GetAllUsers(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, mockUsers, users)
 
@@ -44,13 +84,13 @@ func TestGetAllUsers(t *testing.T) {
 
 func TestCreateUser(t *testing.T) {
 	mockRepo := new(mockUserRepo)
-	inputUser := entity.User{Name: "Bob", Email: "bob@example.com"}
+	inputUser := entity.User{Name: "Bob", Email: "bob@example.com", Password: "Secret#123"}
 	returnUser := entity.User{ID: 2, Name: "Bob", Email: "bob@example.com"}
 
-	mockRepo.On("Create", inputUser).Return(returnUser, nil)
+	mockRepo.On("Create", withName("Bob", "bob@example.com")).Return(returnUser, nil)
 
-	uc := NewUserUsecase(mockRepo)
-	user, err := uc.CreateUser(inputUser)
+	uc := NewUserUsecase(mockRepo, newTestHasher(t), emailutil.Normalizer{})
+	user, err := uc.CreateUser(context.Background(), inputUser)
 	assert.NoError(t, err)
 	assert.Equal(t, returnUser, user)
 
@@ -59,14 +99,49 @@ func TestCreateUser(t *testing.T) {
 
 func TestCreateUser_Error(t *testing.T) {
 	mockRepo := new(mockUserRepo)
-	inputUser := entity.User{Name: "Bob", Email: "bob@example.com"}
+	inputUser := entity.User{Name: "Bob", Email: "bob@example.com", Password: "Secret#123"}
 
-	mockRepo.On("Create", inputUser).Return(entity.User{}, errors.New("create error"))
+	mockRepo.On("Create", withName("Bob", "bob@example.com")).Return(entity.User{}, errors.New("create error"))
 
-	uc := NewUserUsecase(mockRepo)
-	user, err := uc.CreateUser(inputUser)
+	uc := NewUserUsecase(mockRepo, newTestHasher(t), emailutil.Normalizer{})
+	user, err := uc.CreateUser(context.Background(), inputUser)
 	assert.Error(t, err)
 	assert.Equal(t, entity.User{}, user)
 
 	mockRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestCreateUser_NormalizesEmail(t *testing.T) {
+	mockRepo := new(mockUserRepo)
+	normalizer, err := emailutil.New(emailutil.LocalPartPreserve)
+	assert.NoError(t, err)
+
+	mockRepo.On("Create", withName("Bob", "Bob@example.com")).
+		Return(entity.User{ID: 2, Name: "Bob", Email: "Bob@example.com"}, nil)
+
+	uc := NewUserUsecase(mockRepo, newTestHasher(t), normalizer)
+	_, err = uc.CreateUser(context.Background(), entity.User{Name: "Bob", Email: " Bob@EXAMPLE.com"})
+	assert.NoError(t, err)
+
+	_, err = uc.CreateUser(context.Background(), entity.User{Name: "Bob", Email: "bob"})
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateUser_HashesPassword(t *testing.T) {
+	hasher := newTestHasher(t)
+	mockRepo := new(mockUserRepo)
+	var stored entity.User
+	mockRepo.On("Create", withName("Bob", "bob@example.com")).
+		Run(func(args mock.Arguments) { stored = args.Get(0).(entity.User) }).
+		Return(entity.User{ID: 2, Name: "Bob", Email: "bob@example.com"}, nil)
+
+	uc := NewUserUsecase(mockRepo, hasher, emailutil.Normalizer{})
+	_, err := uc.CreateUser(context.Background(), entity.User{Name: "Bob", Email: "bob@example.com", Password: "Secret#123"})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(stored.Password, "$argon2id$"), "stored password is not a PHC hash: %q", stored.Password)
+	assert.NoError(t, hasher.Verify("Secret#123", stored.Password))
+	mockRepo.AssertExpectations(t)
+}
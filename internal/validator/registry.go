@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/ipxsandbox/internal/pkg/logger"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+)
+
+// EmailLookup คือส่วนของ user.Repository ที่ rule unique_email ใช้
+type EmailLookup interface {
+	FindByEmail(ctx context.Context, email string) (entity.User, error)
+}
+
+// Dependencies คือสิ่งที่ rule ซึ่งต้องตรวจกับข้อมูลภายนอกใช้
+type Dependencies struct {
+	Users    EmailLookup
+	Password passwordpolicy.Policy
+}
+
+// Registry รวม rule ทุกแบบไว้ที่เดียว ทั้ง tag ทั่วไป rule ที่ต้องใช้ dependency
+// และ rule ระดับ struct ที่เทียบหลาย field ของ DTO
+type Registry struct {
+	v *validator.Validate
+}
+
+func NewRegistry(deps Dependencies) *Registry {
+	v := validator.New()
+	RegisterCustomValidators(v)
+	registerPasswordPolicy(v, deps.Password)
+	v.RegisterValidationCtx("unique_email", uniqueEmail(deps.Users))
+	return &Registry{v: v}
+}
+
+// RegisterStruct ลงทะเบียน rule ระดับ struct ให้ type ของ types ทุกตัว
+// rule รายงาน error ด้วย ReportError โดยใช้ชื่อ field ตาม json tag
+func (r *Registry) RegisterStruct(fn validator.StructLevelFuncCtx, types ...any) {
+	r.v.RegisterStructValidationCtx(fn, types...)
+}
+
+// Struct ตรวจ s ด้วย ctx ของ request เพื่อให้ rule ที่อ่านฐานข้อมูลยกเลิกตาม request ได้
+func (r *Registry) Struct(ctx context.Context, s any) error {
+	return r.v.StructCtx(ctx, s)
+}
+
+// uniqueEmail ผ่านเมื่อยังไม่มี user ที่ใช้ email นี้ (ไม่สนตัวพิมพ์ตาม repository)
+// ถ้าอ่านฐานข้อมูลไม่ได้จะปล่อยผ่าน เพราะ unique index ยังกันข้อมูลซ้ำไว้อีกชั้น
+func uniqueEmail(users EmailLookup) validator.FuncCtx {
+	return func(ctx context.Context, fl validator.FieldLevel) bool {
+		email := strings.TrimSpace(fl.Field().String())
+		if email == "" {
+			return true
+		}
+		_, err := users.FindByEmail(ctx, email)
+		switch {
+		case err == nil:
+			return false
+		case apperror.KindOf(err) == apperror.KindNotFound:
+			return true
+		}
+		logger.FromContext(ctx).Warn("unique_email lookup failed", slog.Any("error", err))
+		return true
+	}
+}
+
+// MustDiffer รายงาน error nefield ที่ field เมื่อ same(value, other) เป็นจริง
+// field และ otherField เป็นชื่อตาม json tag ใช้ใน rule ระดับ struct
+func MustDiffer(sl validator.StructLevel, field, value, otherField, other string, same func(a, b string) bool) {
+	if value == "" || other == "" || !same(value, other) {
+		return
+	}
+	sl.ReportError(value, field, field, "nefield", otherField)
+}
@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lookupFunc func(ctx context.Context, email string) (entity.User, error)
+
+func (f lookupFunc) FindByEmail(ctx context.Context, email string) (entity.User, error) {
+	return f(ctx, email)
+}
+
+type invite struct {
+	Email string `json:"email" validate:"required,unique_email"`
+	Code  string `json:"code"`
+}
+
+func TestUniqueEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		valid bool
+	}{
+		{"taken", nil, false},
+		{"free", apperror.NotFound("user not found"), true},
+		{"lookup failure is left to the unique index", errors.New("connection refused"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(Dependencies{Users: lookupFunc(func(ctx context.Context, email string) (entity.User, error) {
+				assert.Equal(t, "bob@example.com", email)
+				return entity.User{ID: 1}, tt.err
+			})})
+			err := r.Struct(context.Background(), invite{Email: " bob@example.com "})
+			assert.Equal(t, tt.valid, err == nil, "error: %v", err)
+		})
+	}
+}
+
+func TestRegisterStruct(t *testing.T) {
+	r := NewRegistry(Dependencies{Users: lookupFunc(func(ctx context.Context, email string) (entity.User, error) {
+		return entity.User{}, apperror.NotFound("user not found")
+	})})
+	r.RegisterStruct(func(ctx context.Context, sl validator.StructLevel) {
+		i := sl.Current().Interface().(invite)
+		MustDiffer(sl, "code", i.Code, "email", i.Email, func(a, b string) bool { return a == b })
+	}, invite{})
+
+	require.NoError(t, r.Struct(context.Background(), invite{Email: "bob@example.com", Code: "abc"}))
+
+	err := r.Struct(context.Background(), invite{Email: "bob@example.com", Code: "bob@example.com"})
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "code", Code: "nefield", Params: map[string]string{"param": "email"}, Message: "code must be different from Email"},
+	}, TranslateValidationError(err, DefaultLocale))
+}
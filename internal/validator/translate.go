@@ -0,0 +1,203 @@
+package validator
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/apperror"
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale ใช้เมื่อ client ไม่ระบุภาษาหรือระบุภาษาที่ไม่มี catalog
+// และเป็นภาษาสำรองเมื่อ catalog อื่นไม่มีข้อความของ tag นั้น
+const DefaultLocale = "en"
+
+// defaultTag คือ key ของข้อความสำหรับ tag ที่ไม่มีข้อความเฉพาะ
+const defaultTag = "default"
+
+// fieldRefTags คือ tag ที่ param เป็นชื่อ field อื่น จึงแปลงเป็นชื่อที่แสดงก่อนใส่ในข้อความ
+var fieldRefTags = map[string]bool{"eqfield": true, "nefield": true}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalog คือไฟล์ locales/<locale>.json ข้อความใช้ {field} {param} และ {tag} แทนตำแหน่งของค่า
+// เพื่อให้แต่ละภาษาเรียงคำได้เอง
+type catalog struct {
+	// Fields คือชื่อที่แสดงของ field ตาม json tag
+	Fields   map[string]string `json:"fields"`
+	Messages map[string]string `json:"messages"`
+}
+
+var (
+	catalogs map[string]catalog
+	// localeNames เรียงตรงกับ tag ที่ให้ matcher โดย DefaultLocale อยู่ลำดับแรก
+	localeNames []string
+	matcher     language.Matcher
+)
+
+func init() {
+	if err := loadCatalogs(); err != nil {
+		panic(err)
+	}
+}
+
+func loadCatalogs() error {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		return err
+	}
+	catalogs = make(map[string]catalog, len(files))
+	for _, f := range files {
+		data, err := localeFS.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			return err
+		}
+		var c catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("validation catalog %s: %w", f.Name(), err)
+		}
+		catalogs[strings.TrimSuffix(f.Name(), ".json")] = c
+	}
+	if _, ok := catalogs[DefaultLocale]; !ok {
+		return fmt.Errorf("validation catalog for default locale %q is missing", DefaultLocale)
+	}
+
+	localeNames = []string{DefaultLocale}
+	for _, name := range slices.Sorted(maps.Keys(catalogs)) {
+		if name != DefaultLocale {
+			localeNames = append(localeNames, name)
+		}
+	}
+	tags := make([]language.Tag, len(localeNames))
+	for i, name := range localeNames {
+		tag, err := language.Parse(name)
+		if err != nil {
+			return fmt.Errorf("validation catalog %s.json: %w", name, err)
+		}
+		tags[i] = tag
+	}
+	matcher = language.NewMatcher(tags)
+	return nil
+}
+
+// Locales คืนภาษาที่มี catalog โดย DefaultLocale อยู่ลำดับแรก
+func Locales() []string {
+	return slices.Clone(localeNames)
+}
+
+func IsSupportedLocale(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// HasMessage บอกว่า catalog ของ locale มีข้อความเฉพาะของ tag หรือไม่ ใช้ตรวจว่าแปลครบ
+func HasMessage(locale, tag string) bool {
+	_, ok := catalogs[locale].Messages[tag]
+	return ok
+}
+
+// NegotiateLocale เลือกภาษาที่มี catalog จาก preference ตามลำดับ แต่ละค่าเป็นได้ทั้งรหัสภาษา
+// เช่น locale ในโปรไฟล์ของ user หรือค่าของ header Accept-Language เช่น "th-TH,th;q=0.9,en;q=0.8"
+// ค่าที่ว่าง ผิดรูปแบบ หรือไม่ตรงกับภาษาใดจะถูกข้าม ถ้าไม่มีค่าไหนใช้ได้จะคืน DefaultLocale
+func NegotiateLocale(preferences ...string) string {
+	for _, pref := range preferences {
+		if strings.TrimSpace(pref) == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(pref)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		if _, i, confidence := matcher.Match(tags...); confidence != language.No {
+			return localeNames[i]
+		}
+	}
+	return DefaultLocale
+}
+
+// TranslateValidationError แปล error ของ validator เป็นรายการ error ของ field ตามลำดับที่ตรวจพบ
+// ชื่อ field เป็น path ตาม json tag เช่น items[0].name และ code คือ tag ของเงื่อนไขที่ไม่ผ่าน
+func TranslateValidationError(err error, locale string) []apperror.FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []apperror.FieldError{{Code: "invalid", Message: err.Error()}}
+	}
+	fields := make([]apperror.FieldError, len(errs))
+	for i, e := range errs {
+		// ActualTag คือเงื่อนไขที่ไม่ผ่านจริงเมื่อ tag เป็น alias เช่น password
+		fields[i] = NewFieldError(locale, fieldPath(e), e.ActualTag(), e.Param())
+	}
+	return fields
+}
+
+// NewFieldError สร้าง error ของ field พร้อมข้อความตามภาษา locale ให้ error ที่ไม่ได้มาจาก validator
+// เช่น JSON ที่ถอดไม่ได้หรือ query parameter ที่ผิดรูปแบบ มีรูปแบบเดียวกับ TranslateValidationError
+func NewFieldError(locale, field, code, param string) apperror.FieldError {
+	fe := apperror.FieldError{Field: field, Code: code, Message: message(locale, field, code, param)}
+	if param != "" {
+		fe.Params = map[string]string{"param": param}
+	}
+	return fe
+}
+
+// fieldPath ตัดชื่อ struct ชั้นนอกสุดออกจาก namespace เช่น CreateOrderRequest.items[0].name เป็น items[0].name
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func message(locale, field, code, param string) string {
+	c, ok := catalogs[locale]
+	if !ok {
+		c = catalogs[DefaultLocale]
+	}
+	fallback := catalogs[DefaultLocale]
+
+	template, ok := c.Messages[code]
+	if !ok {
+		template, ok = fallback.Messages[code]
+	}
+	if !ok {
+		template = lookup(defaultTag, c.Messages, fallback.Messages)
+	}
+	if fieldRefTags[code] {
+		param = lookup(displayKey(param), c.Fields, fallback.Fields)
+	}
+	return strings.NewReplacer(
+		"{field}", lookup(displayKey(field), c.Fields, fallback.Fields),
+		"{param}", param,
+		"{tag}", code,
+	).Replace(template)
+}
+
+// displayKey คือชื่อ field ชั้นในสุดที่ไม่มี index ใช้หาชื่อที่แสดงใน catalog
+// เช่น items[0].name เป็น name และ tags[2] เป็น tags
+func displayKey(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		path = path[i+1:]
+	}
+	name, _, _ := strings.Cut(path, "[")
+	return name
+}
+
+// lookup หา key ใน catalog ของภาษาที่เลือกก่อน แล้วจึงใช้ DefaultLocale และคืน key เองเมื่อไม่พบ
+func lookup(key string, primary, fallback map[string]string) string {
+	if v, ok := primary[key]; ok {
+		return v
+	}
+	if v, ok := fallback[key]; ok {
+		return v
+	}
+	return key
+}
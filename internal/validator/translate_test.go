@@ -0,0 +1,160 @@
+package validator
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/apperror"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signup struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,password"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,locale"`
+}
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+	RegisterCustomValidators(v)
+	registerPasswordPolicy(v, passwordpolicy.Default())
+	return v
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences []string
+		want        string
+	}{
+		{"nothing given", nil, DefaultLocale},
+		{"profile wins over header", []string{"th", "en-US,en;q=0.9"}, "th"},
+		{"empty profile falls back to header", []string{"", "th-TH,th;q=0.9,en;q=0.8"}, "th"},
+		{"quality order", []string{"en;q=0.5, th;q=0.8"}, "th"},
+		{"unsupported language", []string{"fr-FR,fr;q=0.9"}, DefaultLocale},
+		{"malformed header", []string{";;;q=x"}, DefaultLocale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NegotiateLocale(tt.preferences...))
+		})
+	}
+}
+
+func TestTranslateValidationErrorUsesJSONNamesAndLocale(t *testing.T) {
+	err := newValidate().Struct(signup{Email: "bad", Password: "short", Locale: "fr"})
+	require.Error(t, err)
+
+	en := TranslateValidationError(err, "en")
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "email", Code: "email", Message: "Email must be a valid email address"},
+		{Field: "password", Code: "min", Params: map[string]string{"param": "8"}, Message: "Password must be at least 8 characters long"},
+		{Field: "locale", Code: "locale", Message: "Locale must be a supported language"},
+	}, en)
+
+	th := TranslateValidationError(err, "th")
+	if assert.Len(t, th, 3) {
+		assert.Equal(t, "อีเมลต้องเป็นอีเมลที่ถูกต้อง", th[0].Message)
+		assert.Equal(t, "รหัสผ่านต้องมีอย่างน้อย 8 ตัวอักษร", th[1].Message)
+	}
+
+	assert.Equal(t, en, TranslateValidationError(err, "xx"), "unknown locale should fall back to the default")
+}
+
+func TestTranslateValidationErrorUnknownTag(t *testing.T) {
+	err := newValidate().Struct(struct {
+		Name string `json:"name" validate:"startswith=x"`
+	}{Name: "abc"})
+	require.Error(t, err)
+	assert.Equal(t, "Name failed validation on 'startswith' rule", TranslateValidationError(err, "en")[0].Message)
+	assert.Equal(t, "ชื่อ ไม่ผ่านเงื่อนไข 'startswith'", TranslateValidationError(err, "th")[0].Message)
+}
+
+func TestTranslateValidationErrorNestedAndSliceFields(t *testing.T) {
+	type item struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type order struct {
+		Owner struct {
+			Email string `json:"email" validate:"email"`
+		} `json:"owner"`
+		Items []item   `json:"items" validate:"min=1,dive"`
+		Tags  []string `json:"tags" validate:"dive,max=3"`
+	}
+	o := order{Items: []item{{Name: "ok"}, {}}, Tags: []string{"a", "toolong"}}
+	o.Owner.Email = "bad"
+
+	fields := TranslateValidationError(newValidate().Struct(o), "en")
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "owner.email", Code: "email", Message: "Email must be a valid email address"},
+		{Field: "items[1].name", Code: "required", Message: "Name is required"},
+		{Field: "tags[1]", Code: "max", Params: map[string]string{"param": "3"}, Message: "tags must not exceed 3 characters"},
+	}, fields)
+}
+
+func TestCatalogsCoverEveryTag(t *testing.T) {
+	base := catalogs[DefaultLocale]
+	for _, tag := range CustomTags {
+		assert.Contains(t, base.Messages, tag, "default catalog has no message for custom tag %q", tag)
+	}
+	for _, locale := range Locales() {
+		c := catalogs[locale]
+		assert.ElementsMatch(t, slices.Collect(maps.Keys(base.Messages)), slices.Collect(maps.Keys(c.Messages)), "messages of %s", locale)
+		assert.ElementsMatch(t, slices.Collect(maps.Keys(base.Fields)), slices.Collect(maps.Keys(c.Fields)), "fields of %s", locale)
+	}
+}
+
+func TestPasswordPolicyReportsFailedRule(t *testing.T) {
+	v := validator.New()
+	RegisterCustomValidators(v)
+	registerPasswordPolicy(v, passwordpolicy.Policy{MinLength: 10, MaxLength: 20, RequireDigit: true, MaxRepeated: 2})
+
+	tests := []struct {
+		password string
+		code     string
+	}{
+		{"short1", "min"},
+		{"averyveryverylongpassword1", "max"},
+		{"no digits here", "password_digit"},
+		{"paaassword1", "password_repeat"},
+		{"lowercase only 1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			err := v.Struct(struct {
+				Password string `json:"password" validate:"password"`
+			}{tt.password})
+			if tt.code == "" {
+				assert.NoError(t, err)
+				return
+			}
+			fields := TranslateValidationError(err, DefaultLocale)
+			if assert.Len(t, fields, 1) {
+				assert.Equal(t, tt.code, fields[0].Code)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyCountsBytesForBcrypt(t *testing.T) {
+	v := validator.New()
+	RegisterCustomValidators(v)
+	registerPasswordPolicy(v, passwordpolicy.Policy{MinLength: 8, MaxLength: 72, MaxBytes: 72})
+
+	type request struct {
+		Password string `json:"password" validate:"password"`
+	}
+	// ตัวอักษรไทย 3 byte ต่อตัว 24 ตัวยาว 72 byte พอดี
+	thai := strings.Repeat("รหัส", 6)
+	assert.NoError(t, v.Struct(request{thai}))
+
+	err := v.Struct(request{thai + "ผ"})
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "password", Code: "password_bytes", Params: map[string]string{"param": "72"}, Message: "Password must be at most 72 bytes; non-Latin characters take more than one byte each"},
+	}, TranslateValidationError(err, DefaultLocale))
+}
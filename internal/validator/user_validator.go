@@ -1,24 +1,69 @@
 package validator
 
 import (
-	"regexp"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/ipxsandbox/internal/pkg/passwordpolicy"
 )
 
+// registerPasswordPolicy ลงทะเบียน tag ของแต่ละเงื่อนไขใน policy และให้ password เป็น alias ของเงื่อนไขที่เปิดไว้
+// error จึงบอกได้ว่าไม่ผ่านเงื่อนไขไหน (ดู FieldError.ActualTag)
+func registerPasswordPolicy(v *validator.Validate, policy passwordpolicy.Policy) {
+	v.RegisterValidation("password_upper", stringRule(passwordpolicy.HasUpper))
+	v.RegisterValidation("password_lower", stringRule(passwordpolicy.HasLower))
+	v.RegisterValidation("password_digit", stringRule(passwordpolicy.HasDigit))
+	v.RegisterValidation("password_special", stringRule(passwordpolicy.HasSpecial))
+	v.RegisterValidation("password_repeat", passwordRepeat)
+	v.RegisterValidation("password_bytes", passwordBytes)
 
-func PasswordValidator(fl validator.FieldLevel) bool {
-	password := fl.Field().String()
+	rules := []string{fmt.Sprintf("min=%d", policy.MinLength)}
+	if policy.MaxLength > 0 {
+		rules = append(rules, fmt.Sprintf("max=%d", policy.MaxLength))
+	}
+	if policy.MaxBytes > 0 {
+		rules = append(rules, fmt.Sprintf("password_bytes=%d", policy.MaxBytes))
+	}
+	for _, r := range []struct {
+		enabled bool
+		tag     string
+	}{
+		{policy.RequireUpper, "password_upper"},
+		{policy.RequireLower, "password_lower"},
+		{policy.RequireDigit, "password_digit"},
+		{policy.RequireSpecial, "password_special"},
+	} {
+		if r.enabled {
+			rules = append(rules, r.tag)
+		}
+	}
+	if policy.MaxRepeated > 0 {
+		rules = append(rules, fmt.Sprintf("password_repeat=%d", policy.MaxRepeated))
+	}
+	v.RegisterAlias("password", strings.Join(rules, ","))
+}
+
+func stringRule(fn func(string) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return fn(fl.Field().String())
+	}
+}
 
-	var (
-		upper   = regexp.MustCompile(`[A-Z]`)
-		lower   = regexp.MustCompile(`[a-z]`)
-		digit   = regexp.MustCompile(`[0-9]`)
-		special = regexp.MustCompile(`[\W_]`)
-	)
+func passwordRepeat(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("password_repeat: invalid param %q", fl.Param()))
+	}
+	return passwordpolicy.LongestRun(fl.Field().String()) <= limit
+}
 
-	return upper.MatchString(password) &&
-		lower.MatchString(password) &&
-		digit.MatchString(password) &&
-		special.MatchString(password)
+// passwordBytes นับความยาวเป็น byte ตามที่ hash algorithm อย่าง bcrypt จำกัดไว้ ไม่ใช่จำนวนตัวอักษรแบบ max
+func passwordBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("password_bytes: invalid param %q", fl.Param()))
+	}
+	return len(fl.Field().String()) <= limit
 }
@@ -0,0 +1,9 @@
+// Package migrations เก็บไฟล์ SQL ของ schema แยกตาม dialect
+// ชื่อไฟล์เป็น <version>_<name>.up.sql และ <version>_<name>.down.sql
+// สร้างไฟล์ใหม่ด้วย go run ./cmd/migrate create <name>
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql mysql/*.sql sqlite/*.sql
+var FS embed.FS
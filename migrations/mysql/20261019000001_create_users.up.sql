@@ -0,0 +1,8 @@
+CREATE TABLE users (
+    id       BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name     VARCHAR(255) NOT NULL,
+    email    VARCHAR(255) NOT NULL COLLATE utf8mb4_0900_as_ci,
+    password VARCHAR(255) NOT NULL,
+    role     VARCHAR(32) NOT NULL DEFAULT 'user',
+    CONSTRAINT uni_users_email UNIQUE (email)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_0900_as_ci;
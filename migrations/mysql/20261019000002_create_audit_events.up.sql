@@ -0,0 +1,17 @@
+CREATE TABLE audit_events (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(6) NOT NULL,
+    action     VARCHAR(64) NOT NULL,
+    outcome    VARCHAR(32) NOT NULL,
+    actor_id   BIGINT UNSIGNED NULL,
+    target     VARCHAR(255) NOT NULL DEFAULT '',
+    ip         VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    detail     TEXT NOT NULL,
+    INDEX idx_audit_events_created_at (created_at),
+    INDEX idx_audit_events_action (action),
+    INDEX idx_audit_events_outcome (outcome),
+    INDEX idx_audit_events_actor_id (actor_id),
+    INDEX idx_audit_events_target (target)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_0900_as_ci;
@@ -0,0 +1,2 @@
+-- collation _ci เป็นค่าตั้งแต่ตอนสร้างตาราง จึงไม่มีอะไรต้องย้อน
+SELECT 1;
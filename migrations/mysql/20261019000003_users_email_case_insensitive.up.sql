@@ -0,0 +1,4 @@
+-- unique index ของ MySQL เทียบตาม collation ของ column จึงบังคับให้ email ใช้ collation แบบ _as_ci
+-- ไม่สนตัวพิมพ์แต่แยกเครื่องหมายกำกับเสียง (_ai_ci จะถือว่า josé กับ jose เป็น email เดียวกัน)
+-- เผื่อตารางถูกสร้างไว้ก่อนด้วย collation อื่น
+ALTER TABLE users MODIFY email VARCHAR(255) NOT NULL COLLATE utf8mb4_0900_as_ci;
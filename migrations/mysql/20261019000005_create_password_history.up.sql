@@ -0,0 +1,8 @@
+CREATE TABLE password_history (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    password   VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    INDEX idx_password_history_user_id (user_id, id),
+    CONSTRAINT fk_password_history_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_0900_as_ci;
@@ -0,0 +1,4 @@
+-- user ที่มีอยู่แล้วเริ่มนับอายุรหัสผ่านจากตอนที่ migrate
+ALTER TABLE users
+    ADD COLUMN password_changed_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,8 @@
+CREATE TABLE users (
+    id       BIGSERIAL PRIMARY KEY,
+    name     TEXT NOT NULL,
+    email    TEXT NOT NULL,
+    password TEXT NOT NULL,
+    role     TEXT NOT NULL DEFAULT 'user',
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
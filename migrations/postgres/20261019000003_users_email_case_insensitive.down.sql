@@ -0,0 +1,2 @@
+DROP INDEX uni_users_email_ci;
+ALTER TABLE users ADD CONSTRAINT uni_users_email UNIQUE (email);
@@ -0,0 +1,4 @@
+-- email ที่ต่างกันแค่ตัวพิมพ์เล็กใหญ่ถือเป็นบัญชีเดียวกัน
+-- ถ้ามีข้อมูลซ้ำอยู่แล้ว migration นี้จะล้มเหลว ให้รัน go run ./cmd/emailcheck เพื่อดูรายการก่อน
+ALTER TABLE users DROP CONSTRAINT uni_users_email;
+CREATE UNIQUE INDEX uni_users_email_ci ON users (LOWER(email));
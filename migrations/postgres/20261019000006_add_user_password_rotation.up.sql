@@ -0,0 +1,3 @@
+-- user ที่มีอยู่แล้วเริ่มนับอายุรหัสผ่านจากตอนที่ migrate
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- email ที่ต่างกันแค่ตัวพิมพ์เล็กใหญ่ถือเป็นบัญชีเดียวกัน
+-- sqlite ลบ constraint เดิมไม่ได้ถ้าไม่สร้างตารางใหม่ จึงเพิ่ม index ที่เข้มกว่าไว้คู่กัน
+CREATE UNIQUE INDEX uni_users_email_ci ON users (email COLLATE NOCASE);
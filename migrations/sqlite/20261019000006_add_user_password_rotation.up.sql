@@ -0,0 +1,4 @@
+-- sqlite ไม่ให้ ADD COLUMN ที่ default เป็น CURRENT_TIMESTAMP จึงตั้งค่าของ user ที่มีอยู่แล้วแยกต่างหาก
+ALTER TABLE users ADD COLUMN password_changed_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+ALTER TABLE users ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET password_changed_at = CURRENT_TIMESTAMP;